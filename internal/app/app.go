@@ -21,7 +21,7 @@ type (
 	App struct {
 		logger     *zap.Logger
 		kube       kubernetes.Interface
-		az         azure.Interface
+		az         azure.Factory
 		server     *httpserver.Server
 		controller *controller.Controller
 		interrupt  chan struct{}
@@ -34,9 +34,14 @@ func New(cfg *config.Config, l *zap.Logger) (*App, error) {
 		return nil, errors.Wrap(err, "failed to initialize kubernetes client")
 	}
 
-	az, err := azure.NewForConfig(&cfg.Azure)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to initialize azure client")
+	var az azure.Factory
+	if cfg.Azure.Enabled {
+		az, err = azure.NewForConfig(&cfg.Azure)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize azure client")
+		}
+	} else {
+		l.Info("Azure provider disabled, only kubernetes resources are managed")
 	}
 
 	hs := httpserver.New(http.NewRouter(), httpserver.Port(cfg.Http.Port))
@@ -1,71 +1,208 @@
 package azure
 
 import (
-	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appcontainers/armappcontainers"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appservice/armappservice"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v3"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysqlflexibleservers"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
 )
 
 type (
 	Config struct {
-		AuthType       string `env-required:"true" json:"auth_type" env:"AZURE_AUTH_TYPE"`
-		SubscriptionId string `env-required:"true" json:"subscription_id" env:"AZURE_SUBSCRIPTION_ID"`
+		// Enabled switches on azure provider, controller manages only kubernetes resources without it.
+		Enabled        bool   `json:"enabled" env:"AZURE_ENABLED"`
+		AuthType       string `json:"auth_type" env:"AZURE_AUTH_TYPE"`
+		SubscriptionId string `json:"subscription_id" env:"AZURE_SUBSCRIPTION_ID"`
+		// settings of workload-identity, client-secret and client-certificate auth types,
+		// env names are the same as used by azure sdk and workload identity webhook
+		TenantId            string `json:"tenant_id" env:"AZURE_TENANT_ID"`
+		ClientId            string `json:"client_id" env:"AZURE_CLIENT_ID"`
+		ClientSecret        string `json:"client_secret" env:"AZURE_CLIENT_SECRET"`
+		CertificatePath     string `json:"client_certificate_path" env:"AZURE_CLIENT_CERTIFICATE_PATH"`
+		CertificatePassword string `json:"client_certificate_password" env:"AZURE_CLIENT_CERTIFICATE_PASSWORD"`
+		FederatedTokenFile  string `json:"federated_token_file" env:"AZURE_FEDERATED_TOKEN_FILE"`
 	}
 	client struct {
-		cred  azcore.TokenCredential
-		cfg   *Config
-		mysql *armmysql.ServersClient
-		vms   *armcompute.VirtualMachinesClient
+		cred          azcore.TokenCredential
+		subscription  string
+		mysql         *armmysql.ServersClient
+		mysqlFlexible *armmysqlflexibleservers.ServersClient
+		pgFlexible    *armpostgresqlflexibleservers.ServersClient
+		vms           *armcompute.VirtualMachinesClient
+		vmss          *armcompute.VirtualMachineScaleSetsClient
+		clusters      *armcontainerservice.ManagedClustersClient
+		pools         *armcontainerservice.AgentPoolsClient
+		webApps       *armappservice.WebAppsClient
+		containerApps *armappcontainers.ContainerAppsClient
 	}
 )
 
-func NewForConfig(cfg *Config) (Interface, error) {
+func NewForConfig(cfg *Config) (Factory, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	switch cfg.AuthType {
-	case "default":
+	case AuthDefault:
 		return NewForDefaultAuth(cfg)
-	case "msi":
+	case AuthMsi:
 		return NewForMsiAuth(cfg)
 	default:
-		return nil, errors.New("invalid access type specified")
+		return NewForCredentials(cfg)
 	}
 }
 
-func NewForMsiAuth(cfg *Config) (Interface, error) {
+// NewForCredentials creates factory authorized with workload identity, client secret or client certificate.
+func NewForCredentials(cfg *Config) (Factory, error) {
+	c, err := cfg.credentials().NewCredential()
+	if err != nil {
+		return nil, err
+	}
+	return NewFactory(c, cfg), nil
+}
+
+func NewForMsiAuth(cfg *Config) (Factory, error) {
 	c, err := azidentity.NewManagedIdentityCredential(nil)
 	if err != nil {
 		return nil, err
 	}
-	return NewClient(c, cfg)
+	return NewFactory(c, cfg), nil
 }
 
-func NewForDefaultAuth(cfg *Config) (Interface, error) {
+func NewForDefaultAuth(cfg *Config) (Factory, error) {
 	c, err := azidentity.NewDefaultAzureCredential(nil)
 	if err != nil {
 		return nil, err
 	}
-	return NewClient(c, cfg)
+	return NewFactory(c, cfg), nil
 }
 
-func NewClient(cred azcore.TokenCredential, cfg *Config) (Interface, error) {
+// NewClient creates client of subscription, options are shared by clients of all azure apis.
+func NewClient(cred azcore.TokenCredential, subscriptionId string, options *arm.ClientOptions) (Interface, error) {
 	client := &client{
-		cred: cred,
-		cfg:  cfg,
+		cred:         cred,
+		subscription: subscriptionId,
 	}
 
-	mysql, err := armmysql.NewServersClient(client.cfg.SubscriptionId, client.cred, nil)
+	mysql, err := armmysql.NewServersClient(client.subscription, client.cred, options)
 	if err != nil {
 		return nil, err
 	}
 	client.mysql = mysql
 
-	vms, err := armcompute.NewVirtualMachinesClient(client.cfg.SubscriptionId, client.cred, nil)
+	mysqlFlexible, err := armmysqlflexibleservers.NewServersClient(client.subscription, client.cred, options)
+	if err != nil {
+		return nil, err
+	}
+	client.mysqlFlexible = mysqlFlexible
+
+	pgFlexible, err := armpostgresqlflexibleservers.NewServersClient(client.subscription, client.cred, options)
+	if err != nil {
+		return nil, err
+	}
+	client.pgFlexible = pgFlexible
+
+	vms, err := armcompute.NewVirtualMachinesClient(client.subscription, client.cred, options)
 	if err != nil {
 		return nil, err
 	}
 	client.vms = vms
 
+	vmss, err := armcompute.NewVirtualMachineScaleSetsClient(client.subscription, client.cred, options)
+	if err != nil {
+		return nil, err
+	}
+	client.vmss = vmss
+
+	clusters, err := armcontainerservice.NewManagedClustersClient(client.subscription, client.cred, options)
+	if err != nil {
+		return nil, err
+	}
+	client.clusters = clusters
+
+	pools, err := armcontainerservice.NewAgentPoolsClient(client.subscription, client.cred, options)
+	if err != nil {
+		return nil, err
+	}
+	client.pools = pools
+
+	webApps, err := armappservice.NewWebAppsClient(client.subscription, client.cred, options)
+	if err != nil {
+		return nil, err
+	}
+	client.webApps = webApps
+
+	containerApps, err := armappcontainers.NewContainerAppsClient(client.subscription, client.cred, options)
+	if err != nil {
+		return nil, err
+	}
+	client.containerApps = containerApps
+
 	return client, nil
 }
+
+// Validate checks that auth type is supported and its settings are specified, all missing ones are reported.
+func (cfg *Config) Validate() error {
+	type setting struct {
+		name  string
+		value string
+	}
+	var (
+		tenant   = setting{name: "tenant_id (AZURE_TENANT_ID)", value: cfg.TenantId}
+		client   = setting{name: "client_id (AZURE_CLIENT_ID)", value: cfg.ClientId}
+		required []setting
+	)
+
+	if cfg.SubscriptionId == "" {
+		return fmt.Errorf("azure subscription_id (AZURE_SUBSCRIPTION_ID) is required")
+	}
+
+	switch cfg.AuthType {
+	case AuthDefault, AuthMsi:
+	case AuthClientSecret:
+		required = []setting{tenant, client,
+			{name: "client_secret (AZURE_CLIENT_SECRET)", value: cfg.ClientSecret}}
+	case AuthClientCertificate:
+		required = []setting{tenant, client,
+			{name: "client_certificate_path (AZURE_CLIENT_CERTIFICATE_PATH)", value: cfg.CertificatePath}}
+	case AuthWorkloadIdentity:
+		required = []setting{tenant, client,
+			{name: "federated_token_file (AZURE_FEDERATED_TOKEN_FILE)", value: cfg.FederatedTokenFile}}
+	default:
+		return fmt.Errorf("invalid azure auth type %q specified, supported: %s", cfg.AuthType, strings.Join([]string{
+			AuthDefault, AuthMsi, AuthWorkloadIdentity, AuthClientSecret, AuthClientCertificate,
+		}, ", "))
+	}
+
+	var missing []string
+	for _, s := range required {
+		if s.value == "" {
+			missing = append(missing, s.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("azure auth type %s requires %s", cfg.AuthType, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (cfg *Config) credentials() *Credentials {
+	return &Credentials{
+		AuthType:            cfg.AuthType,
+		TenantId:            cfg.TenantId,
+		ClientId:            cfg.ClientId,
+		ClientSecret:        cfg.ClientSecret,
+		TokenFile:           cfg.FederatedTokenFile,
+		CertificatePath:     cfg.CertificatePath,
+		CertificatePassword: cfg.CertificatePassword,
+	}
+}
@@ -0,0 +1,65 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConfigValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		cfg      Config
+		expError string
+	}{
+		{
+			name: "default",
+			cfg:  Config{SubscriptionId: "sub", AuthType: "default"},
+		},
+		{
+			name:     "without subscription",
+			cfg:      Config{AuthType: "default"},
+			expError: "azure subscription_id (AZURE_SUBSCRIPTION_ID) is required",
+		},
+		{
+			name: "client secret",
+			cfg:  Config{SubscriptionId: "sub", AuthType: "client-secret", TenantId: "tenant", ClientId: "client", ClientSecret: "secret"},
+		},
+		{
+			name:     "client secret without secret",
+			cfg:      Config{SubscriptionId: "sub", AuthType: "client-secret", TenantId: "tenant", ClientId: "client"},
+			expError: "azure auth type client-secret requires client_secret (AZURE_CLIENT_SECRET)",
+		},
+		{
+			name: "workload identity without settings",
+			cfg:  Config{SubscriptionId: "sub", AuthType: "workload-identity"},
+			expError: "azure auth type workload-identity requires tenant_id (AZURE_TENANT_ID), " +
+				"client_id (AZURE_CLIENT_ID), federated_token_file (AZURE_FEDERATED_TOKEN_FILE)",
+		},
+		{
+			name:     "client certificate without path",
+			cfg:      Config{SubscriptionId: "sub", AuthType: "client-certificate", TenantId: "tenant", ClientId: "client"},
+			expError: "azure auth type client-certificate requires client_certificate_path (AZURE_CLIENT_CERTIFICATE_PATH)",
+		},
+		{
+			name: "unsupported",
+			cfg:  Config{SubscriptionId: "sub", AuthType: "cli"},
+			expError: `invalid azure auth type "cli" specified, supported: ` +
+				"default, msi, workload-identity, client-secret, client-certificate",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+
+			if tc.expError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expError)
+			}
+		})
+	}
+}
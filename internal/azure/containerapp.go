@@ -0,0 +1,101 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appcontainers/armappcontainers"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+// captureContainerApp captures min replicas of container app, they are not captured when app is already scaled to zero.
+func (c *client) captureContainerApp(ctx context.Context, resource *Resource) error {
+	app, err := c.getContainerApp(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	if replicas := util.Deref(app.Properties.Template.Scale.MinReplicas); replicas > 0 {
+		resource.SetReplicas(&apis.ContainerAppScale{MinReplicas: replicas})
+	}
+	return nil
+}
+
+// shutdownContainerApp scales container app to zero, its replicas must be captured and stored by caller beforehand.
+// App scaled to zero still starts replicas on incoming requests, so it is stopped only without traffic.
+func (c *client) shutdownContainerApp(ctx context.Context, resource *Resource, wait bool) error {
+	app, err := c.getContainerApp(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	scale := app.Properties.Template.Scale
+	// already scaled to zero (or by previous attempt)
+	if util.Deref(scale.MinReplicas) == 0 {
+		return nil
+	}
+
+	scale.MinReplicas = util.Pointer(int32(0))
+
+	return c.updateContainerApp(ctx, resource, app, wait)
+}
+
+// startupContainerApp restores min replicas captured on shutdown, app is not changed when they are unknown.
+func (c *client) startupContainerApp(ctx context.Context, resource *Resource, wait bool) error {
+	replicas := resource.GetReplicas()
+	if replicas == nil {
+		return nil
+	}
+
+	app, err := c.getContainerApp(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	app.Properties.Template.Scale.MinReplicas = util.Pointer(replicas.MinReplicas)
+
+	return c.updateContainerApp(ctx, resource, app, wait)
+}
+
+func (c *client) getContainerApp(ctx context.Context, resource *Resource) (*armappcontainers.ContainerApp, error) {
+	resp, err := c.containerApps.Get(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &resp.ContainerApp
+	if app.Properties == nil || app.Properties.Template == nil {
+		return nil, fmt.Errorf("container app %s has no template", resource)
+	}
+	if app.Properties.Template.Scale == nil {
+		app.Properties.Template.Scale = &armappcontainers.Scale{}
+	}
+	return app, nil
+}
+
+func (c *client) updateContainerApp(
+	ctx context.Context,
+	resource *Resource,
+	app *armappcontainers.ContainerApp,
+	wait bool,
+) error {
+	template := app.Properties.Template
+	// revision suffix must be unique, keeping it fails creation of revision with updated scale
+	template.RevisionSuffix = nil
+
+	// only template is patched, configuration is skipped as secrets values are not returned by get
+	envelope := armappcontainers.ContainerApp{
+		Location: app.Location,
+		Properties: &armappcontainers.ContainerAppProperties{
+			Template: template,
+		},
+	}
+
+	return ExecuteOperation(ctx, resource, wait,
+		func(ctx context.Context, resource *Resource) (*runtime.Poller[armappcontainers.ContainerAppsClientUpdateResponse], error) {
+			return c.containerApps.BeginUpdate(ctx, resource.GetResourceGroup(), resource.GetName(), envelope, nil)
+		})
+}
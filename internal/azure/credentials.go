@@ -0,0 +1,136 @@
+package azure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+type (
+	// Credentials contains service principal or workload identity settings, e.g. read from policy secret.
+	Credentials struct {
+		AuthType     string
+		TenantId     string
+		ClientId     string
+		ClientSecret string
+		TokenFile    string
+		// CertificatePath and CertificatePassword are used by client-certificate auth, PEM or PKCS#12 is expected.
+		CertificatePath     string
+		CertificatePassword string
+		// Source identifies origin of credentials, e.g. secret namespace and name, changed credentials of the same
+		// source replace cached ones.
+		Source string
+	}
+)
+
+const (
+	AuthDefault           = "default"
+	AuthMsi               = "msi"
+	AuthClientSecret      = "client-secret"
+	AuthClientCertificate = "client-certificate"
+	AuthWorkloadIdentity  = "workload-identity"
+
+	SecretKeyAuthType     = "authType"
+	SecretKeyTenantId     = "tenantId"
+	SecretKeyClientId     = "clientId"
+	SecretKeyClientSecret = "clientSecret"
+
+	_FederatedTokenFileEnv = "AZURE_FEDERATED_TOKEN_FILE"
+)
+
+// CredentialsFromSecret reads credentials from secret data, auth type is client-secret when secret contains
+// client secret and workload-identity (with token of controller service account) otherwise.
+func CredentialsFromSecret(data map[string][]byte) (*Credentials, error) {
+	cred := &Credentials{
+		AuthType:     string(data[SecretKeyAuthType]),
+		TenantId:     string(data[SecretKeyTenantId]),
+		ClientId:     string(data[SecretKeyClientId]),
+		ClientSecret: string(data[SecretKeyClientSecret]),
+	}
+	if cred.AuthType == "" {
+		cred.AuthType = AuthWorkloadIdentity
+		if cred.ClientSecret != "" {
+			cred.AuthType = AuthClientSecret
+		}
+	}
+	if cred.AuthType == AuthWorkloadIdentity {
+		cred.TokenFile = os.Getenv(_FederatedTokenFileEnv)
+	}
+	return cred, cred.Validate()
+}
+
+// Validate checks that settings required by auth type are specified, setting names are secret keys.
+func (c *Credentials) Validate() error {
+	required := map[string]string{
+		SecretKeyTenantId: c.TenantId,
+		SecretKeyClientId: c.ClientId,
+	}
+	switch c.AuthType {
+	case AuthClientSecret:
+		required[SecretKeyClientSecret] = c.ClientSecret
+	case AuthWorkloadIdentity:
+		required[_FederatedTokenFileEnv] = c.TokenFile
+	default:
+		return fmt.Errorf("unsupported auth type %q", c.AuthType)
+	}
+
+	for name, value := range required {
+		if value == "" {
+			return fmt.Errorf("%s is required for auth type %s", name, c.AuthType)
+		}
+	}
+	return nil
+}
+
+// GetSource returns origin of credentials, it is empty for controller ones.
+func (c *Credentials) GetSource() string {
+	if c == nil {
+		return ""
+	}
+	return c.Source
+}
+
+// Key returns hash of credentials, so changed ones are not served from cache.
+func (c *Credentials) Key() string {
+	if c == nil {
+		return ""
+	}
+	h := sha256.New()
+	values := []string{c.AuthType, c.TenantId, c.ClientId, c.ClientSecret, c.TokenFile, c.CertificatePath, c.CertificatePassword}
+	for _, value := range values {
+		_, _ = h.Write([]byte(value))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewCredential creates token credential for settings, they are expected to be validated.
+func (c *Credentials) NewCredential() (azcore.TokenCredential, error) {
+	switch c.AuthType {
+	case AuthClientSecret:
+		return azidentity.NewClientSecretCredential(c.TenantId, c.ClientId, c.ClientSecret, nil)
+	case AuthClientCertificate:
+		data, err := os.ReadFile(c.CertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		certs, key, err := azidentity.ParseCertificates(data, []byte(c.CertificatePassword))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate: %w", err)
+		}
+		return azidentity.NewClientCertificateCredential(c.TenantId, c.ClientId, certs, key, nil)
+	case AuthWorkloadIdentity:
+		// token file is rotated by kubelet, so it is read on every token request
+		return azidentity.NewClientAssertionCredential(c.TenantId, c.ClientId,
+			func(context.Context) (string, error) {
+				token, err := os.ReadFile(c.TokenFile)
+				return string(token), err
+			}, nil)
+	}
+	return nil, fmt.Errorf("unsupported auth type %q", c.AuthType)
+}
@@ -0,0 +1,78 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CredentialsFromSecret(t *testing.T) {
+	cases := []struct {
+		name        string
+		data        map[string][]byte
+		tokenFile   string
+		expAuthType string
+		expError    bool
+	}{
+		{
+			name: "client secret",
+			data: map[string][]byte{
+				"tenantId":     []byte("tenant"),
+				"clientId":     []byte("client"),
+				"clientSecret": []byte("secret"),
+			},
+			expAuthType: AuthClientSecret,
+		},
+		{
+			name: "workload identity",
+			data: map[string][]byte{
+				"tenantId": []byte("tenant"),
+				"clientId": []byte("client"),
+			},
+			tokenFile:   "/var/run/secrets/azure/tokens/azure-identity-token",
+			expAuthType: AuthWorkloadIdentity,
+		},
+		{
+			name: "workload identity without token file",
+			data: map[string][]byte{
+				"tenantId": []byte("tenant"),
+				"clientId": []byte("client"),
+			},
+			expAuthType: AuthWorkloadIdentity,
+			expError:    true,
+		},
+		{
+			name: "client secret without tenant",
+			data: map[string][]byte{
+				"authType":     []byte("client-secret"),
+				"clientId":     []byte("client"),
+				"clientSecret": []byte("secret"),
+			},
+			expAuthType: AuthClientSecret,
+			expError:    true,
+		},
+		{
+			name: "unsupported auth type",
+			data: map[string][]byte{
+				"authType": []byte("msi"),
+				"tenantId": []byte("tenant"),
+				"clientId": []byte("client"),
+			},
+			expAuthType: "msi",
+			expError:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(_FederatedTokenFileEnv, tc.tokenFile)
+
+			cred, err := CredentialsFromSecret(tc.data)
+
+			assert.Equal(t, tc.expError, err != nil)
+			assert.Equal(t, tc.expAuthType, cred.AuthType)
+		})
+	}
+}
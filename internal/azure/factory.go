@@ -0,0 +1,97 @@
+package azure
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"k8s.io/utils/clock"
+)
+
+type (
+	Factory interface {
+		// Get returns client for subscription and credentials, controller ones are used when not specified.
+		Get(subscriptionId string, cred *Credentials) (Interface, error)
+	}
+
+	factory struct {
+		lock          sync.Mutex
+		cred          azcore.TokenCredential
+		subscription  string
+		credentials   map[string]cached[azcore.TokenCredential]
+		subscriptions map[string]cached[Interface]
+		guards        map[string]*guard
+	}
+
+	// cached is entry of credentials source, which is replaced when credentials hash is changed.
+	cached[T any] struct {
+		key   string
+		value T
+	}
+)
+
+// NewFactory creates factory, which caches clients per subscription and source of credentials.
+// Rate limit and circuit breaker are shared by clients of subscription, regardless of credentials.
+func NewFactory(cred azcore.TokenCredential, cfg *Config) Factory {
+	return &factory{
+		cred:          cred,
+		subscription:  cfg.SubscriptionId,
+		credentials:   map[string]cached[azcore.TokenCredential]{},
+		subscriptions: map[string]cached[Interface]{},
+		guards:        map[string]*guard{},
+	}
+}
+
+func (f *factory) Get(subscriptionId string, cred *Credentials) (Interface, error) {
+	if subscriptionId == "" {
+		subscriptionId = f.subscription
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	source := fmt.Sprintf("%s/%s", subscriptionId, cred.GetSource())
+	if entry, ok := f.subscriptions[source]; ok && entry.key == cred.Key() {
+		return entry.value, nil
+	}
+
+	tc, err := f.getCredential(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	g, ok := f.guards[subscriptionId]
+	if !ok {
+		g = newGuard(clock.RealClock{})
+		f.guards[subscriptionId] = g
+	}
+
+	c, err := NewClient(tc, subscriptionId, g.clientOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	client := &guardedClient{client: c, guard: g}
+	f.subscriptions[source] = cached[Interface]{key: cred.Key(), value: client}
+	return client, nil
+}
+
+// getCredential returns credential shared by clients of all subscriptions, so tokens are cached once.
+func (f *factory) getCredential(cred *Credentials) (azcore.TokenCredential, error) {
+	if cred == nil {
+		return f.cred, nil
+	}
+
+	key := cred.Key()
+	if entry, ok := f.credentials[cred.Source]; ok && entry.key == key {
+		return entry.value, nil
+	}
+
+	tc, err := cred.NewCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	f.credentials[cred.Source] = cached[azcore.TokenCredential]{key: key, value: tc}
+	return tc, nil
+}
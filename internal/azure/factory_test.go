@@ -0,0 +1,63 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FactoryGet(t *testing.T) {
+	cases := []struct {
+		name           string
+		creds          []*Credentials
+		expClients     int
+		expCredentials int
+	}{
+		{
+			name:           "controller credentials",
+			creds:          []*Credentials{nil, nil},
+			expClients:     1,
+			expCredentials: 0,
+		},
+		{
+			name: "rotated secret replaces cached client",
+			creds: []*Credentials{
+				{AuthType: AuthClientSecret, TenantId: "tenant", ClientId: "client", ClientSecret: "old", Source: "ns/secret"},
+				{AuthType: AuthClientSecret, TenantId: "tenant", ClientId: "client", ClientSecret: "new", Source: "ns/secret"},
+			},
+			expClients:     1,
+			expCredentials: 1,
+		},
+		{
+			name: "different secrets",
+			creds: []*Credentials{
+				{AuthType: AuthClientSecret, TenantId: "tenant", ClientId: "client", ClientSecret: "secret", Source: "ns/first"},
+				{AuthType: AuthClientSecret, TenantId: "tenant", ClientId: "client", ClientSecret: "secret", Source: "ns/second"},
+			},
+			expClients:     2,
+			expCredentials: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			f := NewFactory(nil, &Config{SubscriptionId: "subscription"}).(*factory)
+
+			var last Interface
+			for _, cred := range tc.creds {
+				client, err := f.Get("", cred)
+				assert.NoError(t, err)
+				last = client
+			}
+
+			assert.Len(t, f.subscriptions, tc.expClients)
+			assert.Len(t, f.credentials, tc.expCredentials)
+			// cached client is returned for unchanged credentials
+			client, err := f.Get("", tc.creds[len(tc.creds)-1])
+			assert.NoError(t, err)
+			assert.Same(t, last, client)
+		})
+	}
+}
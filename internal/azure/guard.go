@@ -0,0 +1,186 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
+)
+
+type (
+	// guard protects subscription from excessive and failing requests, it is shared by all clients of subscription.
+	guard struct {
+		lock      sync.Mutex
+		clock     clock.PassiveClock
+		limiter   flowcontrol.RateLimiter
+		failures  int
+		openUntil time.Time
+	}
+
+	// guardedClient fails operations fast while circuit is open, requests are retried by azure sdk.
+	guardedClient struct {
+		client Interface
+		guard  *guard
+	}
+
+	// limiterPolicy delays requests of subscription over rate limit.
+	limiterPolicy struct {
+		limiter flowcontrol.RateLimiter
+	}
+)
+
+var (
+	ErrCircuitOpen = errors.New("azure provider is degraded after repeated failures")
+
+	// _TransientStatusCodes are throttling and server errors, which are worth to retry.
+	_TransientStatusCodes = []int{
+		http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+const (
+	_RetryMaxRetries = 3
+	_RetryDelay      = time.Second * 4
+	_RetryMaxDelay   = time.Minute
+	_RateLimitQPS    = 10
+	_RateLimitBurst  = 20
+	// consecutive failed operations, after which operations of subscription are not executed until cooldown passed
+	_BreakerThreshold = 5
+	_BreakerCooldown  = time.Minute * 5
+)
+
+func newGuard(c clock.PassiveClock) *guard {
+	return &guard{
+		clock:   c,
+		limiter: flowcontrol.NewTokenBucketRateLimiter(_RateLimitQPS, _RateLimitBurst),
+	}
+}
+
+// clientOptions returns options of azure clients, so all their requests share rate limit of subscription.
+// Requests are retried by sdk with exponential backoff, Retry-After of response takes precedence over it,
+// so operations are not retried as whole and captured state of resources is not read again.
+func (g *guard) clientOptions() *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Retry: policy.RetryOptions{
+				MaxRetries:    _RetryMaxRetries,
+				RetryDelay:    _RetryDelay,
+				MaxRetryDelay: _RetryMaxDelay,
+				StatusCodes:   _TransientStatusCodes,
+			},
+			// every retry is rate limited too
+			PerRetryPolicies: []policy.Policy{&limiterPolicy{limiter: g.limiter}},
+		},
+	}
+}
+
+// execute runs operation, it is not run while circuit is open.
+func (g *guard) execute(ctx context.Context, op func(ctx context.Context) error) error {
+	if err := g.allow(); err != nil {
+		return err
+	}
+
+	err := op(ctx)
+	g.record(ctx, err)
+	return err
+}
+
+// allow returns error, while circuit is open.
+func (g *guard) allow() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.failures >= _BreakerThreshold && g.clock.Now().Before(g.openUntil) {
+		return fmt.Errorf("%w: %d consecutive operations failed, next attempt after %s",
+			ErrCircuitOpen, g.failures, g.openUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// record opens circuit after consecutive transient failures, any response of azure closes it.
+func (g *guard) record(ctx context.Context, err error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// interrupted operation says nothing about azure availability
+	case isTransient(err):
+		g.failures++
+		if g.failures >= _BreakerThreshold {
+			g.openUntil = g.clock.Now().Add(_BreakerCooldown)
+		}
+	default:
+		g.failures = 0
+	}
+}
+
+// isTransient returns true for throttling and server errors, which are left after retries of sdk.
+func isTransient(err error) bool {
+	var re *azcore.ResponseError
+	if !errors.As(err, &re) {
+		return false
+	}
+	for _, code := range _TransientStatusCodes {
+		if re.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *guardedClient) List(ctx context.Context, resourceType ResourceType, resourceGroup string) ([]*Resource, error) {
+	var ret []*Resource
+	err := c.guard.execute(ctx, func(ctx context.Context) (err error) {
+		ret, err = c.client.List(ctx, resourceType, resourceGroup)
+		return err
+	})
+	return ret, err
+}
+
+func (c *guardedClient) Capture(ctx context.Context, resource *Resource) error {
+	return c.guard.execute(ctx, func(ctx context.Context) error {
+		return c.client.Capture(ctx, resource)
+	})
+}
+
+func (c *guardedClient) Shutdown(ctx context.Context, resource *Resource, wait bool) error {
+	return c.guard.execute(ctx, func(ctx context.Context) error {
+		return c.client.Shutdown(ctx, resource, wait)
+	})
+}
+
+func (c *guardedClient) Startup(ctx context.Context, resource *Resource, wait bool) error {
+	return c.guard.execute(ctx, func(ctx context.Context) error {
+		return c.client.Startup(ctx, resource, wait)
+	})
+}
+
+func (c *guardedClient) GetState(ctx context.Context, resource *Resource) (PowerState, error) {
+	state := PowerStateUnknown
+	err := c.guard.execute(ctx, func(ctx context.Context) (err error) {
+		state, err = c.client.GetState(ctx, resource)
+		return err
+	})
+	return state, err
+}
+
+func (p *limiterPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if err := p.limiter.Wait(req.Raw().Context()); err != nil {
+		return nil, err
+	}
+	return req.Next()
+}
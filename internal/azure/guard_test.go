@@ -0,0 +1,128 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+	clock "k8s.io/utils/clock/testing"
+)
+
+var (
+	_Now = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func responseError(statusCode int) error {
+	return &azcore.ResponseError{
+		StatusCode:  statusCode,
+		RawResponse: &http.Response{StatusCode: statusCode},
+	}
+}
+
+func newTestGuard() (*guard, *clock.FakeClock) {
+	c := clock.NewFakeClock(_Now)
+	return newGuard(c), c
+}
+
+func Test_IsTransient(t *testing.T) {
+	cases := []struct {
+		name         string
+		err          error
+		expTransient bool
+	}{
+		{name: "no error", err: nil, expTransient: false},
+		{name: "throttled", err: responseError(http.StatusTooManyRequests), expTransient: true},
+		{name: "server error", err: responseError(http.StatusServiceUnavailable), expTransient: true},
+		{name: "conflict", err: responseError(http.StatusConflict), expTransient: false},
+		{name: "not response error", err: errors.New("unknown"), expTransient: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expTransient, isTransient(tc.err))
+		})
+	}
+}
+
+func Test_GuardClientOptions(t *testing.T) {
+	g, _ := newTestGuard()
+
+	options := g.clientOptions()
+
+	// requests are retried by sdk only, operations are executed once
+	assert.Equal(t, int32(_RetryMaxRetries), options.Retry.MaxRetries)
+	assert.Equal(t, _TransientStatusCodes, options.Retry.StatusCodes)
+	assert.Len(t, options.PerRetryPolicies, 1)
+}
+
+func Test_GuardExecute(t *testing.T) {
+	cases := []struct {
+		name        string
+		err         error
+		expFailures int
+	}{
+		{
+			name:        "success",
+			expFailures: 0,
+		},
+		{
+			name:        "transient error",
+			err:         responseError(http.StatusServiceUnavailable),
+			expFailures: 1,
+		},
+		{
+			name:        "not transient error",
+			err:         responseError(http.StatusConflict),
+			expFailures: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			g, _ := newTestGuard()
+
+			attempts := 0
+			err := g.execute(context.Background(), func(_ context.Context) error {
+				attempts++
+				return tc.err
+			})
+
+			assert.Equal(t, 1, attempts)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.expFailures, g.failures)
+		})
+	}
+}
+
+func Test_GuardCircuit(t *testing.T) {
+	g, c := newTestGuard()
+	fail := func(_ context.Context) error { return responseError(http.StatusServiceUnavailable) }
+	succeed := func(_ context.Context) error { return nil }
+
+	for i := 0; i < _BreakerThreshold; i++ {
+		assert.False(t, errors.Is(g.execute(context.Background(), fail), ErrCircuitOpen))
+	}
+
+	// circuit is open, operation is not executed
+	executed := false
+	err := g.execute(context.Background(), func(_ context.Context) error {
+		executed = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.False(t, executed)
+
+	// operation is allowed after cooldown, its success closes circuit
+	c.Step(_BreakerCooldown)
+	assert.NoError(t, g.execute(context.Background(), succeed))
+	assert.NoError(t, g.allow())
+	assert.Equal(t, 0, g.failures)
+}
@@ -8,47 +8,66 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v3"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysqlflexibleservers"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
 )
 
 type (
 	Interface interface {
 		List(ctx context.Context, resourceType ResourceType, resourceGroup string) ([]*Resource, error)
+		Capture(ctx context.Context, resource *Resource) error
 		Shutdown(ctx context.Context, resource *Resource, wait bool) error
 		Startup(ctx context.Context, resource *Resource, wait bool) error
+		GetState(ctx context.Context, resource *Resource) (PowerState, error)
 	}
 )
 
 var (
 	ErrUnsupportedType = errors.New("unsupported type specified")
+	ErrDisabled        = errors.New("azure provider is disabled in controller config")
 )
 
 const (
 	PollerInterval = time.Second * 10
 )
 
+// List returns resources of type in resource group, or in whole subscription when resource group is empty.
 func (c *client) List(ctx context.Context, resourceType ResourceType, resourceGroup string) ([]*Resource, error) {
 	switch resourceType {
 	case ResourceManagedMySQL:
-		pager := c.mysql.NewListByResourceGroupPager(resourceGroup, nil)
-		return List(ctx, pager,
-			func(pager armmysql.ServersClientListByResourceGroupResponse) []*armmysql.Server {
-				return pager.Value
-			},
-			func(s *armmysql.Server) *Resource { return NewResource(*s.ID) },
-		)
+		return c.listMySQL(ctx, resourceGroup)
+	case ResourceMySQLFlexible:
+		return c.listMySQLFlexible(ctx, resourceGroup)
+	case ResourcePostgreSQLFlexible:
+		return c.listPostgreSQLFlexible(ctx, resourceGroup)
 	case ResourceVirtualMachine:
-		pager := c.vms.NewListPager(resourceGroup, nil)
-		return List(ctx, pager,
-			func(pager armcompute.VirtualMachinesClientListResponse) []*armcompute.VirtualMachine {
-				return pager.Value
-			},
-			func(vm *armcompute.VirtualMachine) *Resource { return NewResource(*vm.ID) },
-		)
+		return c.listVirtualMachines(ctx, resourceGroup)
+	case ResourceVMScaleSet:
+		return c.listVMScaleSets(ctx, resourceGroup)
+	case ResourceAKSNodePool:
+		return c.listNodePools(ctx, resourceGroup)
+	case ResourceAppService, ResourceFunctionApp:
+		return c.listWebApps(ctx, resourceType, resourceGroup)
+	case ResourceContainerApp:
+		return c.listContainerApps(ctx, resourceGroup)
 	default:
 		return nil, ErrUnsupportedType
 	}
 }
 
+// Capture stores in resource scale of node pool or replicas of container app, which are restored on startup.
+// Nothing is captured for other types and for resources already scaled to zero.
+func (c *client) Capture(ctx context.Context, resource *Resource) error {
+	switch resource.GetType() {
+	case ResourceAKSNodePool:
+		return c.captureNodePool(ctx, resource)
+	case ResourceContainerApp:
+		return c.captureContainerApp(ctx, resource)
+	default:
+		return nil
+	}
+}
+
 func (c *client) Shutdown(ctx context.Context, resource *Resource, wait bool) error {
 	switch resource.GetType() {
 	case ResourceManagedMySQL:
@@ -56,11 +75,33 @@ func (c *client) Shutdown(ctx context.Context, resource *Resource, wait bool) er
 			func(ctx context.Context, resource *Resource) (*runtime.Poller[armmysql.ServersClientStopResponse], error) {
 				return c.mysql.BeginStop(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
 			})
+	case ResourceMySQLFlexible:
+		return ExecuteOperation(ctx, resource, wait,
+			func(ctx context.Context, resource *Resource) (*runtime.Poller[armmysqlflexibleservers.ServersClientStopResponse], error) {
+				return c.mysqlFlexible.BeginStop(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+			})
+	case ResourcePostgreSQLFlexible:
+		return ExecuteOperation(ctx, resource, wait,
+			func(ctx context.Context, resource *Resource) (*runtime.Poller[armpostgresqlflexibleservers.ServersClientStopResponse], error) {
+				return c.pgFlexible.BeginStop(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+			})
 	case ResourceVirtualMachine:
 		return ExecuteOperation(ctx, resource, wait,
 			func(ctx context.Context, resource *Resource) (*runtime.Poller[armcompute.VirtualMachinesClientDeallocateResponse], error) {
 				return c.vms.BeginDeallocate(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
 			})
+	case ResourceVMScaleSet:
+		return ExecuteOperation(ctx, resource, wait,
+			func(ctx context.Context, resource *Resource) (*runtime.Poller[armcompute.VirtualMachineScaleSetsClientDeallocateResponse], error) {
+				return c.vmss.BeginDeallocate(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+			})
+	case ResourceAKSNodePool:
+		return c.shutdownNodePool(ctx, resource, wait)
+	case ResourceAppService, ResourceFunctionApp:
+		_, err := c.webApps.Stop(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+		return err
+	case ResourceContainerApp:
+		return c.shutdownContainerApp(ctx, resource, wait)
 	default:
 		return ErrUnsupportedType
 	}
@@ -73,11 +114,33 @@ func (c *client) Startup(ctx context.Context, resource *Resource, wait bool) err
 			func(ctx context.Context, resource *Resource) (*runtime.Poller[armmysql.ServersClientStartResponse], error) {
 				return c.mysql.BeginStart(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
 			})
+	case ResourceMySQLFlexible:
+		return ExecuteOperation(ctx, resource, wait,
+			func(ctx context.Context, resource *Resource) (*runtime.Poller[armmysqlflexibleservers.ServersClientStartResponse], error) {
+				return c.mysqlFlexible.BeginStart(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+			})
+	case ResourcePostgreSQLFlexible:
+		return ExecuteOperation(ctx, resource, wait,
+			func(ctx context.Context, resource *Resource) (*runtime.Poller[armpostgresqlflexibleservers.ServersClientStartResponse], error) {
+				return c.pgFlexible.BeginStart(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+			})
 	case ResourceVirtualMachine:
 		return ExecuteOperation(ctx, resource, wait,
 			func(ctx context.Context, resource *Resource) (*runtime.Poller[armcompute.VirtualMachinesClientStartResponse], error) {
 				return c.vms.BeginStart(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
 			})
+	case ResourceVMScaleSet:
+		return ExecuteOperation(ctx, resource, wait,
+			func(ctx context.Context, resource *Resource) (*runtime.Poller[armcompute.VirtualMachineScaleSetsClientStartResponse], error) {
+				return c.vmss.BeginStart(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+			})
+	case ResourceAKSNodePool:
+		return c.startupNodePool(ctx, resource, wait)
+	case ResourceAppService, ResourceFunctionApp:
+		_, err := c.webApps.Start(ctx, resource.GetResourceGroup(), resource.GetName(), nil)
+		return err
+	case ResourceContainerApp:
+		return c.startupContainerApp(ctx, resource, wait)
 	default:
 		return ErrUnsupportedType
 	}
@@ -0,0 +1,105 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appcontainers/armappcontainers"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v3"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysqlflexibleservers"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+func (c *client) listMySQL(ctx context.Context, resourceGroup string) ([]*Resource, error) {
+	create := func(s *armmysql.Server) *Resource { return NewResource(*s.ID).WithTags(s.Tags) }
+	if resourceGroup == "" {
+		return List(ctx, c.mysql.NewListPager(nil),
+			func(pager armmysql.ServersClientListResponse) []*armmysql.Server { return pager.Value },
+			create)
+	}
+	return List(ctx, c.mysql.NewListByResourceGroupPager(resourceGroup, nil),
+		func(pager armmysql.ServersClientListByResourceGroupResponse) []*armmysql.Server { return pager.Value },
+		create)
+}
+
+func (c *client) listMySQLFlexible(ctx context.Context, resourceGroup string) ([]*Resource, error) {
+	create := func(s *armmysqlflexibleservers.Server) *Resource { return NewResource(*s.ID).WithTags(s.Tags) }
+	if resourceGroup == "" {
+		return List(ctx, c.mysqlFlexible.NewListPager(nil),
+			func(pager armmysqlflexibleservers.ServersClientListResponse) []*armmysqlflexibleservers.Server {
+				return pager.Value
+			},
+			create)
+	}
+	return List(ctx, c.mysqlFlexible.NewListByResourceGroupPager(resourceGroup, nil),
+		func(pager armmysqlflexibleservers.ServersClientListByResourceGroupResponse) []*armmysqlflexibleservers.Server {
+			return pager.Value
+		},
+		create)
+}
+
+func (c *client) listPostgreSQLFlexible(ctx context.Context, resourceGroup string) ([]*Resource, error) {
+	create := func(s *armpostgresqlflexibleservers.Server) *Resource { return NewResource(*s.ID).WithTags(s.Tags) }
+	if resourceGroup == "" {
+		return List(ctx, c.pgFlexible.NewListPager(nil),
+			func(pager armpostgresqlflexibleservers.ServersClientListResponse) []*armpostgresqlflexibleservers.Server {
+				return pager.Value
+			},
+			create)
+	}
+	return List(ctx, c.pgFlexible.NewListByResourceGroupPager(resourceGroup, nil),
+		func(pager armpostgresqlflexibleservers.ServersClientListByResourceGroupResponse) []*armpostgresqlflexibleservers.Server {
+			return pager.Value
+		},
+		create)
+}
+
+func (c *client) listVirtualMachines(ctx context.Context, resourceGroup string) ([]*Resource, error) {
+	create := func(vm *armcompute.VirtualMachine) *Resource { return NewResource(*vm.ID).WithTags(vm.Tags) }
+	if resourceGroup == "" {
+		return List(ctx, c.vms.NewListAllPager(nil),
+			func(pager armcompute.VirtualMachinesClientListAllResponse) []*armcompute.VirtualMachine {
+				return pager.Value
+			},
+			create)
+	}
+	return List(ctx, c.vms.NewListPager(resourceGroup, nil),
+		func(pager armcompute.VirtualMachinesClientListResponse) []*armcompute.VirtualMachine {
+			return pager.Value
+		},
+		create)
+}
+
+func (c *client) listVMScaleSets(ctx context.Context, resourceGroup string) ([]*Resource, error) {
+	create := func(vmss *armcompute.VirtualMachineScaleSet) *Resource {
+		return NewResource(*vmss.ID).WithTags(vmss.Tags)
+	}
+	if resourceGroup == "" {
+		return List(ctx, c.vmss.NewListAllPager(nil),
+			func(pager armcompute.VirtualMachineScaleSetsClientListAllResponse) []*armcompute.VirtualMachineScaleSet {
+				return pager.Value
+			},
+			create)
+	}
+	return List(ctx, c.vmss.NewListPager(resourceGroup, nil),
+		func(pager armcompute.VirtualMachineScaleSetsClientListResponse) []*armcompute.VirtualMachineScaleSet {
+			return pager.Value
+		},
+		create)
+}
+
+func (c *client) listContainerApps(ctx context.Context, resourceGroup string) ([]*Resource, error) {
+	create := func(app *armappcontainers.ContainerApp) *Resource { return NewResource(*app.ID).WithTags(app.Tags) }
+	if resourceGroup == "" {
+		return List(ctx, c.containerApps.NewListBySubscriptionPager(nil),
+			func(pager armappcontainers.ContainerAppsClientListBySubscriptionResponse) []*armappcontainers.ContainerApp {
+				return pager.Value
+			},
+			create)
+	}
+	return List(ctx, c.containerApps.NewListByResourceGroupPager(resourceGroup, nil),
+		func(pager armappcontainers.ContainerAppsClientListByResourceGroupResponse) []*armappcontainers.ContainerApp {
+			return pager.Value
+		},
+		create)
+}
@@ -0,0 +1,163 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+// listNodePools returns user node pools of all AKS clusters in resource group (or subscription),
+// system ones could not be scaled to zero.
+func (c *client) listNodePools(ctx context.Context, resourceGroup string) ([]*Resource, error) {
+	create := func(mc *armcontainerservice.ManagedCluster) *Resource { return NewResource(*mc.ID) }
+
+	var clusters []*Resource
+	var err error
+	if resourceGroup == "" {
+		clusters, err = List(ctx, c.clusters.NewListPager(nil),
+			func(pager armcontainerservice.ManagedClustersClientListResponse) []*armcontainerservice.ManagedCluster {
+				return pager.Value
+			},
+			create)
+	} else {
+		clusters, err = List(ctx, c.clusters.NewListByResourceGroupPager(resourceGroup, nil),
+			func(pager armcontainerservice.ManagedClustersClientListByResourceGroupResponse) []*armcontainerservice.ManagedCluster {
+				return pager.Value
+			},
+			create)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*Resource
+	for _, cluster := range clusters {
+		pager := c.pools.NewListPager(cluster.GetResourceGroup(), cluster.GetName(), nil)
+		pools, err := List(ctx, pager,
+			func(pager armcontainerservice.AgentPoolsClientListResponse) []*armcontainerservice.AgentPool {
+				return util.Where(pager.Value, func(_ int, pool *armcontainerservice.AgentPool) bool {
+					return isUserNodePool(pool)
+				})
+			},
+			func(pool *armcontainerservice.AgentPool) *Resource {
+				return NewResource(*pool.ID).WithTags(pool.Properties.Tags)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, pools...)
+	}
+	return ret, nil
+}
+
+// captureNodePool captures scale of node pool, it is not captured when pool is already scaled to zero.
+func (c *client) captureNodePool(ctx context.Context, resource *Resource) error {
+	pool, err := c.getNodePool(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	props := pool.Properties
+	scale := &apis.NodePoolScale{
+		Count:             util.Deref(props.Count),
+		EnableAutoScaling: util.Deref(props.EnableAutoScaling),
+		MinCount:          util.Deref(props.MinCount),
+		MaxCount:          util.Deref(props.MaxCount),
+	}
+	if !isNodePoolStopped(props) {
+		resource.SetScale(scale)
+	}
+	return nil
+}
+
+// shutdownNodePool scales node pool to zero, its scale must be captured and stored by caller beforehand.
+func (c *client) shutdownNodePool(ctx context.Context, resource *Resource, wait bool) error {
+	pool, err := c.getNodePool(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	props := pool.Properties
+	// already scaled down by previous attempt
+	if isNodePoolStopped(props) {
+		return nil
+	}
+
+	props.Count = util.Pointer(int32(0))
+	props.EnableAutoScaling = util.Pointer(false)
+	props.MinCount = nil
+	props.MaxCount = nil
+
+	return c.updateNodePool(ctx, resource, pool, wait)
+}
+
+// startupNodePool restores node pool scale captured on shutdown, node pool is not changed when scale is unknown.
+func (c *client) startupNodePool(ctx context.Context, resource *Resource, wait bool) error {
+	scale := resource.GetScale()
+	if scale == nil {
+		return nil
+	}
+
+	pool, err := c.getNodePool(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	props := pool.Properties
+	props.Count = util.Pointer(scale.Count)
+	props.EnableAutoScaling = util.Pointer(scale.EnableAutoScaling)
+	props.MinCount = nil
+	props.MaxCount = nil
+	if scale.EnableAutoScaling {
+		props.MinCount = util.Pointer(scale.MinCount)
+		props.MaxCount = util.Pointer(scale.MaxCount)
+	}
+
+	return c.updateNodePool(ctx, resource, pool, wait)
+}
+
+func (c *client) getNodePool(ctx context.Context, resource *Resource) (*armcontainerservice.AgentPool, error) {
+	resp, err := c.pools.Get(ctx, resource.GetResourceGroup(), resource.GetParentName(), resource.GetName(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &resp.AgentPool
+	if pool.Properties == nil {
+		return nil, fmt.Errorf("node pool %s has no properties", resource)
+	}
+	if !isUserNodePool(pool) {
+		return nil, fmt.Errorf("node pool %s is not in user mode", resource)
+	}
+	return pool, nil
+}
+
+func (c *client) updateNodePool(
+	ctx context.Context,
+	resource *Resource,
+	pool *armcontainerservice.AgentPool,
+	wait bool,
+) error {
+	return ExecuteOperation(ctx, resource, wait,
+		func(ctx context.Context, resource *Resource) (*runtime.Poller[armcontainerservice.AgentPoolsClientCreateOrUpdateResponse], error) {
+			return c.pools.BeginCreateOrUpdate(
+				ctx, resource.GetResourceGroup(), resource.GetParentName(), resource.GetName(), *pool, nil)
+		})
+}
+
+func isUserNodePool(pool *armcontainerservice.AgentPool) bool {
+	return pool.Properties != nil &&
+		pool.Properties.Mode != nil &&
+		*pool.Properties.Mode == armcontainerservice.AgentPoolModeUser
+}
+
+// isNodePoolStopped returns true, when node pool is scaled to zero without autoscaling.
+func isNodePoolStopped(props *armcontainerservice.ManagedClusterAgentPoolProfileProperties) bool {
+	return util.Deref(props.Count) == 0 && !util.Deref(props.EnableAutoScaling)
+}
@@ -2,25 +2,60 @@ package azure
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
 )
 
 type (
 	ResourceType string
 
 	Resource struct {
-		id *arm.ResourceID
+		id       *arm.ResourceID
+		kind     string
+		tags     map[string]string
+		scale    *apis.NodePoolScale
+		replicas *apis.ContainerAppScale
+		wait     bool
+		timeout  time.Duration
 	}
 )
 
 const (
-	ResourceManagedMySQL   = ResourceType("Microsoft.DBforMySQL/servers")
-	ResourceVirtualMachine = ResourceType("Microsoft.Compute/virtualMachines")
+	ResourceManagedMySQL       = ResourceType("Microsoft.DBforMySQL/servers")
+	ResourceMySQLFlexible      = ResourceType("Microsoft.DBforMySQL/flexibleServers")
+	ResourcePostgreSQLFlexible = ResourceType("Microsoft.DBforPostgreSQL/flexibleServers")
+	ResourceVirtualMachine     = ResourceType("Microsoft.Compute/virtualMachines")
+	ResourceVMScaleSet         = ResourceType("Microsoft.Compute/virtualMachineScaleSets")
+	ResourceAKSNodePool        = ResourceType("Microsoft.ContainerService/managedClusters/agentPools")
+	ResourceAppService         = ResourceType("Microsoft.Web/sites")
+	ResourceFunctionApp        = ResourceType("Microsoft.Web/sites/functionapp")
+	ResourceContainerApp       = ResourceType("Microsoft.App/containerApps")
 )
 
+const (
+	_FunctionAppKind = "functionapp"
+
+	_DefaultWaitTimeout = time.Minute * 10
+)
+
+// _WaitTimeouts contains default timeouts of operations by resource type, e.g. servers take longer to stop than sites.
+var _WaitTimeouts = map[ResourceType]time.Duration{
+	ResourceManagedMySQL:       time.Minute * 20,
+	ResourceMySQLFlexible:      time.Minute * 20,
+	ResourcePostgreSQLFlexible: time.Minute * 20,
+	ResourceVirtualMachine:     time.Minute * 10,
+	ResourceVMScaleSet:         time.Minute * 15,
+	ResourceAKSNodePool:        time.Minute * 20,
+	ResourceAppService:         time.Minute * 5,
+	ResourceFunctionApp:        time.Minute * 5,
+	ResourceContainerApp:       time.Minute * 5,
+}
+
 func NewResource(rawId string) *Resource {
 	id, _ := arm.ParseResourceID(rawId)
 
@@ -29,14 +64,100 @@ func NewResource(rawId string) *Resource {
 	}
 }
 
+// NewResourceOfKind creates resource, which kind distinguishes it from others of the same azure type.
+func NewResourceOfKind(rawId string, kind string) *Resource {
+	r := NewResource(rawId)
+	r.kind = kind
+	return r
+}
+
+// WithTags sets tags of resource as returned by azure api.
+func (r *Resource) WithTags(tags map[string]*string) *Resource {
+	r.tags = make(map[string]string, len(tags))
+	for key, value := range tags {
+		r.tags[key] = util.Deref(value)
+	}
+	return r
+}
+
+// WithWait sets whether shutdown of resource is awaited and how long, default timeout of type is used for zero one.
+func (r *Resource) WithWait(wait bool, timeout time.Duration) *Resource {
+	r.wait = wait
+	r.timeout = timeout
+	return r
+}
+
 func (r Resource) GetType() ResourceType {
-	return ResourceType(r.id.ResourceType.String())
+	t := ResourceType(r.id.ResourceType.String())
+	// function apps are sites too, they are distinguished by kind, e.g. "functionapp,linux"
+	if strings.EqualFold(string(t), string(ResourceAppService)) && isFunctionApp(r.kind) {
+		return ResourceFunctionApp
+	}
+	return t
 }
 
 func (r Resource) GetName() string {
 	return r.id.Name
 }
 
+// GetTags returns tags of resource.
+func (r Resource) GetTags() map[string]string {
+	return r.tags
+}
+
+// GetParentName returns name of parent resource, e.g. AKS cluster of node pool.
+func (r Resource) GetParentName() string {
+	if r.id.Parent == nil {
+		return ""
+	}
+	return r.id.Parent.Name
+}
+
+// GetID returns full resource id.
+func (r Resource) GetID() string {
+	return r.id.String()
+}
+
+// GetScale returns scale of node pool captured on shutdown, nil when nothing should be restored.
+func (r Resource) GetScale() *apis.NodePoolScale {
+	return r.scale
+}
+
+// SetScale sets scale of node pool to be restored on startup.
+func (r *Resource) SetScale(scale *apis.NodePoolScale) {
+	r.scale = scale
+}
+
+// GetReplicas returns replicas of container app captured on shutdown, nil when nothing should be restored.
+func (r Resource) GetReplicas() *apis.ContainerAppScale {
+	return r.replicas
+}
+
+// SetReplicas sets replicas of container app to be restored on startup.
+func (r *Resource) SetReplicas(replicas *apis.ContainerAppScale) {
+	r.replicas = replicas
+}
+
+// GetWait returns true, when shutdown of resource is awaited, startup is always awaited.
+func (r Resource) GetWait() bool {
+	return r.wait
+}
+
+// GetWaitTimeout returns how long operation on resource is awaited.
+func (r Resource) GetWaitTimeout() time.Duration {
+	if r.timeout > 0 {
+		return r.timeout
+	}
+	if timeout, ok := _WaitTimeouts[r.GetType()]; ok {
+		return timeout
+	}
+	return _DefaultWaitTimeout
+}
+
+func (r Resource) GetSubscriptionId() string {
+	return r.id.SubscriptionID
+}
+
 func (r Resource) GetResourceGroup() string {
 	return r.id.ResourceGroupName
 }
@@ -49,9 +170,27 @@ func From(api apis.AzureResourceType) (ResourceType, error) {
 	switch api {
 	case apis.AzureResourceManagedMySQL:
 		return ResourceManagedMySQL, nil
+	case apis.AzureResourceMySQLFlexible:
+		return ResourceMySQLFlexible, nil
+	case apis.AzureResourcePostgreSQLFlexible:
+		return ResourcePostgreSQLFlexible, nil
 	case apis.AzureResourceVirtualMachine:
 		return ResourceVirtualMachine, nil
+	case apis.AzureResourceVMScaleSet:
+		return ResourceVMScaleSet, nil
+	case apis.AzureResourceAKSNodePool:
+		return ResourceAKSNodePool, nil
+	case apis.AzureResourceAppService:
+		return ResourceAppService, nil
+	case apis.AzureResourceFunctionApp:
+		return ResourceFunctionApp, nil
+	case apis.AzureResourceContainerApp:
+		return ResourceContainerApp, nil
 	default:
 		return "", ErrUnsupportedType
 	}
 }
+
+func isFunctionApp(kind string) bool {
+	return strings.Contains(strings.ToLower(kind), _FunctionAppKind)
+}
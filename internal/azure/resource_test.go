@@ -0,0 +1,86 @@
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResourceGetType(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      string
+		kind    string
+		expType ResourceType
+	}{
+		{
+			name:    "app service",
+			id:      "/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Web/sites/web",
+			kind:    "app,linux",
+			expType: ResourceAppService,
+		},
+		{
+			name:    "function app",
+			id:      "/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Web/sites/func",
+			kind:    "functionapp,linux",
+			expType: ResourceFunctionApp,
+		},
+		{
+			name:    "container app",
+			id:      "/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.App/containerApps/app",
+			expType: ResourceContainerApp,
+		},
+		{
+			name:    "node pool",
+			id:      "/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.ContainerService/managedClusters/aks/agentPools/agents",
+			expType: ResourceAKSNodePool,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			resource := NewResourceOfKind(tc.id, tc.kind)
+
+			assert.Equal(t, tc.expType, resource.GetType())
+		})
+	}
+}
+
+func Test_ResourceGetWaitTimeout(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		timeout    time.Duration
+		expTimeout time.Duration
+	}{
+		{
+			name:       "default of type",
+			id:         "/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.DBforMySQL/servers/db",
+			expTimeout: time.Minute * 20,
+		},
+		{
+			name:       "specified",
+			id:         "/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.DBforMySQL/servers/db",
+			timeout:    time.Minute,
+			expTimeout: time.Minute,
+		},
+		{
+			name:       "default of unknown type",
+			id:         "/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Storage/storageAccounts/storage",
+			expTimeout: _DefaultWaitTimeout,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			resource := NewResource(tc.id).WithWait(true, tc.timeout)
+
+			assert.Equal(t, tc.expTimeout, resource.GetWaitTimeout())
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package azure
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v3"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+type (
+	PowerState string
+)
+
+const (
+	PowerStateRunning  = PowerState("Running")
+	PowerStateStarting = PowerState("Starting")
+	PowerStateStopped  = PowerState("Stopped")
+	PowerStateStopping = PowerState("Stopping")
+	PowerStateUnknown  = PowerState("Unknown")
+	// PowerStateAllocated is state of virtual machine powered off from inside OS, it is still billed until deallocated.
+	PowerStateAllocated = PowerState("Allocated")
+)
+
+const (
+	_PowerStateCodePrefix = "PowerState/"
+)
+
+// IsStopped returns true, when resource is stopped or its stop is in progress.
+func (s PowerState) IsStopped() bool {
+	return s == PowerStateStopped || s == PowerStateStopping
+}
+
+// IsRunning returns true, when resource is running or its start is in progress.
+func (s PowerState) IsRunning() bool {
+	return s == PowerStateRunning || s == PowerStateStarting
+}
+
+func (c *client) GetState(ctx context.Context, resource *Resource) (PowerState, error) {
+	rg, name := resource.GetResourceGroup(), resource.GetName()
+
+	switch resource.GetType() {
+	case ResourceManagedMySQL:
+		resp, err := c.mysql.Get(ctx, rg, name, nil)
+		if err != nil || resp.Properties == nil {
+			return PowerStateUnknown, err
+		}
+		return parsePowerState(string(util.Deref(resp.Properties.UserVisibleState))), nil
+	case ResourceMySQLFlexible:
+		resp, err := c.mysqlFlexible.Get(ctx, rg, name, nil)
+		if err != nil || resp.Properties == nil {
+			return PowerStateUnknown, err
+		}
+		return parsePowerState(string(util.Deref(resp.Properties.State))), nil
+	case ResourcePostgreSQLFlexible:
+		resp, err := c.pgFlexible.Get(ctx, rg, name, nil)
+		if err != nil || resp.Properties == nil {
+			return PowerStateUnknown, err
+		}
+		return parsePowerState(string(util.Deref(resp.Properties.State))), nil
+	case ResourceVirtualMachine:
+		resp, err := c.vms.InstanceView(ctx, rg, name, nil)
+		if err != nil {
+			return PowerStateUnknown, err
+		}
+		for _, status := range resp.Statuses {
+			if code := util.Deref(status.Code); strings.HasPrefix(code, _PowerStateCodePrefix) {
+				return parseVMPowerState(strings.TrimPrefix(code, _PowerStateCodePrefix)), nil
+			}
+		}
+		return PowerStateUnknown, nil
+	case ResourceVMScaleSet:
+		resp, err := c.vmss.GetInstanceView(ctx, rg, name, nil)
+		if err != nil || resp.VirtualMachine == nil {
+			return PowerStateUnknown, err
+		}
+		return summarizePowerState(resp.VirtualMachine.StatusesSummary), nil
+	case ResourceAKSNodePool:
+		pool, err := c.getNodePool(ctx, resource)
+		if err != nil {
+			return PowerStateUnknown, err
+		}
+		if isNodePoolStopped(pool.Properties) {
+			return PowerStateStopped, nil
+		}
+		return PowerStateRunning, nil
+	case ResourceAppService, ResourceFunctionApp:
+		resp, err := c.webApps.Get(ctx, rg, name, nil)
+		if err != nil || resp.Properties == nil {
+			return PowerStateUnknown, err
+		}
+		return parsePowerState(util.Deref(resp.Properties.State)), nil
+	case ResourceContainerApp:
+		app, err := c.getContainerApp(ctx, resource)
+		if err != nil {
+			return PowerStateUnknown, err
+		}
+		if util.Deref(app.Properties.Template.Scale.MinReplicas) == 0 {
+			return PowerStateStopped, nil
+		}
+		return PowerStateRunning, nil
+	default:
+		return PowerStateUnknown, ErrUnsupportedType
+	}
+}
+
+// parsePowerState maps states of different azure apis, e.g. Ready of databases or deallocated of VMs.
+func parsePowerState(state string) PowerState {
+	switch strings.ToLower(state) {
+	case "ready", "running":
+		return PowerStateRunning
+	case "starting":
+		return PowerStateStarting
+	case "stopped", "deallocated":
+		return PowerStateStopped
+	case "stopping", "deallocating":
+		return PowerStateStopping
+	default:
+		return PowerStateUnknown
+	}
+}
+
+// parseVMPowerState maps state of virtual machine, only deallocated one is stopped, as stopped one is still billed.
+func parseVMPowerState(state string) PowerState {
+	if strings.EqualFold(state, "stopped") {
+		return PowerStateAllocated
+	}
+	return parsePowerState(state)
+}
+
+// summarizePowerState returns state of scale set by states of its instances, it is running while any instance runs
+// and stopped only when all instances are deallocated.
+func summarizePowerState(summary []*armcompute.VirtualMachineStatusCodeCount) PowerState {
+	counts := map[PowerState]int32{}
+	for _, s := range summary {
+		if code := util.Deref(s.Code); strings.HasPrefix(code, _PowerStateCodePrefix) {
+			counts[parseVMPowerState(strings.TrimPrefix(code, _PowerStateCodePrefix))] += util.Deref(s.Count)
+		}
+	}
+
+	for _, state := range []PowerState{
+		PowerStateRunning, PowerStateStarting, PowerStateStopping, PowerStateAllocated, PowerStateUnknown,
+	} {
+		if counts[state] > 0 {
+			return state
+		}
+	}
+	return PowerStateStopped
+}
@@ -0,0 +1,114 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+func Test_ParsePowerState(t *testing.T) {
+	cases := []struct {
+		state    string
+		expState PowerState
+	}{
+		{state: "Ready", expState: PowerStateRunning},
+		{state: "running", expState: PowerStateRunning},
+		{state: "Starting", expState: PowerStateStarting},
+		{state: "Stopped", expState: PowerStateStopped},
+		{state: "deallocated", expState: PowerStateStopped},
+		{state: "Stopping", expState: PowerStateStopping},
+		{state: "deallocating", expState: PowerStateStopping},
+		{state: "Updating", expState: PowerStateUnknown},
+		{state: "", expState: PowerStateUnknown},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.state, func(t *testing.T) {
+			assert.Equal(t, tc.expState, parsePowerState(tc.state))
+		})
+	}
+}
+
+func Test_ParseVMPowerState(t *testing.T) {
+	cases := []struct {
+		state    string
+		expState PowerState
+	}{
+		{state: "running", expState: PowerStateRunning},
+		{state: "stopped", expState: PowerStateAllocated},
+		{state: "deallocated", expState: PowerStateStopped},
+		{state: "deallocating", expState: PowerStateStopping},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.state, func(t *testing.T) {
+			state := parseVMPowerState(tc.state)
+
+			assert.Equal(t, tc.expState, state)
+			assert.Equal(t, tc.expState == PowerStateStopped || tc.expState == PowerStateStopping, state.IsStopped())
+		})
+	}
+}
+
+func Test_SummarizePowerState(t *testing.T) {
+	count := func(code string, count int32) *armcompute.VirtualMachineStatusCodeCount {
+		return &armcompute.VirtualMachineStatusCodeCount{Code: util.Pointer(code), Count: util.Pointer(count)}
+	}
+
+	cases := []struct {
+		name     string
+		summary  []*armcompute.VirtualMachineStatusCodeCount
+		expState PowerState
+	}{
+		{
+			name:     "no instances",
+			expState: PowerStateStopped,
+		},
+		{
+			name:     "all deallocated",
+			summary:  []*armcompute.VirtualMachineStatusCodeCount{count("PowerState/deallocated", 3)},
+			expState: PowerStateStopped,
+		},
+		{
+			name: "any running",
+			summary: []*armcompute.VirtualMachineStatusCodeCount{
+				count("PowerState/deallocated", 2),
+				count("PowerState/running", 1),
+			},
+			expState: PowerStateRunning,
+		},
+		{
+			name: "stopping",
+			summary: []*armcompute.VirtualMachineStatusCodeCount{
+				count("PowerState/stopped", 2),
+				count("PowerState/deallocating", 1),
+			},
+			expState: PowerStateStopping,
+		},
+		{
+			name: "stopped but allocated",
+			summary: []*armcompute.VirtualMachineStatusCodeCount{
+				count("PowerState/deallocated", 2),
+				count("PowerState/stopped", 1),
+			},
+			expState: PowerStateAllocated,
+		},
+		{
+			name:     "provisioning codes ignored",
+			summary:  []*armcompute.VirtualMachineStatusCodeCount{count("ProvisioningState/succeeded", 2)},
+			expState: PowerStateStopped,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expState, summarizePowerState(tc.summary))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appservice/armappservice"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+// listWebApps returns sites of specified type, app services and function apps share api and differ by kind.
+func (c *client) listWebApps(ctx context.Context, resourceType ResourceType, resourceGroup string) ([]*Resource, error) {
+	create := func(site *armappservice.Site) *Resource {
+		return NewResourceOfKind(*site.ID, util.Deref(site.Kind)).WithTags(site.Tags)
+	}
+
+	var sites []*Resource
+	var err error
+	if resourceGroup == "" {
+		sites, err = List(ctx, c.webApps.NewListPager(nil),
+			func(pager armappservice.WebAppsClientListResponse) []*armappservice.Site { return pager.Value },
+			create)
+	} else {
+		sites, err = List(ctx, c.webApps.NewListByResourceGroupPager(resourceGroup, nil),
+			func(pager armappservice.WebAppsClientListByResourceGroupResponse) []*armappservice.Site {
+				return pager.Value
+			},
+			create)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Where(sites, func(_ int, site *Resource) bool {
+		return site.GetType() == resourceType
+	}), nil
+}
@@ -3,16 +3,31 @@ package controller
 import (
 	"time"
 
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
 	"github.com/dodopizza/stand-schedule-policy-controller/pkg/worker"
 )
 
 type (
 	Config struct {
-		ObjectsResyncSeconds  int `json:"core_resync_seconds" env:"CONTROLLER_OBJECTS_RESYNC_SECONDS"`
-		PoliciesResyncSeconds int `json:"policies_resync_seconds" env:"CONTROLLER_POLICIES_RESYNC_SECONDS"`
-		ReconcilerThreadiness int `json:"reconciler_threadiness" env:"CONTROLLER_RECONCILER_THREADINESS"`
-		ExecutorThreadiness   int `json:"executor_threadiness" env:"CONTROLLER_EXECUTOR_THREADINESS"`
-		WorkerQueueRetries    int `json:"worker_queue_retries" env:"CONTROLLER_WORKER_QUEUE_RETRIES"`
+		ObjectsResyncSeconds  int            `json:"core_resync_seconds" env:"CONTROLLER_OBJECTS_RESYNC_SECONDS"`
+		PoliciesResyncSeconds int            `json:"policies_resync_seconds" env:"CONTROLLER_POLICIES_RESYNC_SECONDS"`
+		ReconcilerThreadiness int            `json:"reconciler_threadiness" env:"CONTROLLER_RECONCILER_THREADINESS"`
+		ExecutorThreadiness   int            `json:"executor_threadiness" env:"CONTROLLER_EXECUTOR_THREADINESS"`
+		WorkerQueueRetries    int            `json:"worker_queue_retries" env:"CONTROLLER_WORKER_QUEUE_RETRIES"`
+		MaxExecutions         int            `json:"max_executions" env:"CONTROLLER_MAX_EXECUTIONS"`
+		CredentialsNamespace  string         `json:"credentials_namespace" env:"CONTROLLER_CREDENTIALS_NAMESPACE"`
+		Timeouts              TimeoutsConfig `json:"timeouts"`
+	}
+	TimeoutsConfig struct {
+		ExecutionSeconds           int `json:"execution_seconds" env:"CONTROLLER_EXECUTION_TIMEOUT_SECONDS"`
+		DeadlineSeconds            int `json:"deadline_seconds" env:"CONTROLLER_DEADLINE_TIMEOUT_SECONDS"`
+		WaitStatefulSetPodsSeconds int `json:"wait_statefulset_pods_seconds" env:"CONTROLLER_WAIT_STATEFULSET_PODS_SECONDS"`
+		WaitDeploymentPodsSeconds  int `json:"wait_deployment_pods_seconds" env:"CONTROLLER_WAIT_DEPLOYMENT_PODS_SECONDS"`
+		WaitTerminatingPodsSeconds int `json:"wait_terminating_pods_seconds" env:"CONTROLLER_WAIT_TERMINATING_PODS_SECONDS"`
+		WaitPodsIntervalSeconds    int `json:"wait_pods_interval_seconds" env:"CONTROLLER_WAIT_PODS_INTERVAL_SECONDS"`
+		WaitWaveSeconds            int `json:"wait_wave_seconds" env:"CONTROLLER_WAIT_WAVE_SECONDS"`
 	}
 )
 
@@ -24,7 +39,17 @@ const (
 	_MinWorkerQueueRetries        = 1
 	_DefaultReconcilerThreadiness = 1
 	_DefaultExecutorThreadiness   = 1
+	_DefaultEnforcerThreadiness   = 1
+	_DefaultFinalizerThreadiness  = 1
 	_MinThreadiness               = 1
+	_DefaultExecutionSeconds      = 2700 // 45 min
+	_DefaultDeadlineSeconds       = 5460 // 91 min
+	_DefaultWaitStsPodsSeconds    = 180  // 3 min
+	_DefaultWaitDeployPodsSeconds = 60   // 1 min
+	_DefaultWaitTermPodsSeconds   = 60   // 1 min
+	_DefaultWaitPodsInterval      = 15
+	_DefaultWaitWaveSeconds       = 300 // 5 min
+	_MinTimeoutSeconds            = 1
 )
 
 func (c *Config) GetObjectsResyncInterval() time.Duration {
@@ -58,6 +83,51 @@ func (c *Config) GetExecutorConfig() *worker.Config {
 	}
 }
 
+func (c *Config) GetEnforcerConfig() *worker.Config {
+	return &worker.Config{
+		Name:        "enforcer",
+		Retries:     c.GetWorkerQueueRetries(),
+		Threadiness: _DefaultEnforcerThreadiness,
+	}
+}
+
+func (c *Config) GetFinalizerConfig() *worker.Config {
+	return &worker.Config{
+		Name:        "finalizer",
+		Retries:     c.GetWorkerQueueRetries(),
+		Threadiness: _DefaultFinalizerThreadiness,
+	}
+}
+
+// GetMaxExecutions returns limit of simultaneous startups and shutdowns, zero means no limit.
+func (c *Config) GetMaxExecutions() int {
+	if c.MaxExecutions < 0 {
+		return 0
+	}
+	return c.MaxExecutions
+}
+
+// GetTimeouts returns default timeouts, used when policy not specifies them.
+func (c *Config) GetTimeouts() apis.TimeoutsSpec {
+	t := c.Timeouts
+	return apis.TimeoutsSpec{
+		Execution:           getTimeout(t.ExecutionSeconds, _DefaultExecutionSeconds),
+		Deadline:            getTimeout(t.DeadlineSeconds, _DefaultDeadlineSeconds),
+		WaitStatefulSetPods: getTimeout(t.WaitStatefulSetPodsSeconds, _DefaultWaitStsPodsSeconds),
+		WaitDeploymentPods:  getTimeout(t.WaitDeploymentPodsSeconds, _DefaultWaitDeployPodsSeconds),
+		WaitTerminatingPods: getTimeout(t.WaitTerminatingPodsSeconds, _DefaultWaitTermPodsSeconds),
+		WaitPodsInterval:    getTimeout(t.WaitPodsIntervalSeconds, _DefaultWaitPodsInterval),
+		WaitWave:            getTimeout(t.WaitWaveSeconds, _DefaultWaitWaveSeconds),
+	}
+}
+
+func getTimeout(actual, def int) meta.Duration {
+	if actual < _MinTimeoutSeconds {
+		actual = def
+	}
+	return meta.Duration{Duration: time.Duration(actual) * time.Second}
+}
+
 func getThreadiness(actual, min, def int) int {
 	if actual < min {
 		return def
@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	apps "k8s.io/api/apps/v1"
 
 	util "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
@@ -28,8 +30,14 @@ type (
 		factory  *kubernetes.FactoryGroup
 		lister   *kubernetes.ListerGroup
 		events   *eventsource.EventSource[apis.StandSchedulePolicy]
+		deploys  *eventsource.EventSource[apps.Deployment]
+		sts      *eventsource.EventSource[apps.StatefulSet]
 		workers  []*worker.Worker
 		executor *executor.Executor
+		recorder record.EventRecorder
+		timeouts apis.TimeoutsSpec
+		running  *executions
+		limiter  *limiter
 	}
 )
 
@@ -38,7 +46,7 @@ func NewController(
 	l *zap.Logger,
 	clock clock.WithTicker,
 	k kubernetes.Interface,
-	az azure.Interface,
+	az azure.Factory,
 ) *Controller {
 	c := &Controller{
 		notify: make(chan error, 1),
@@ -47,6 +55,9 @@ func NewController(
 		clock:  clock,
 		state:  state.New(),
 	}
+	c.running = newExecutions()
+	c.limiter = newLimiter(cfg.GetMaxExecutions())
+	c.timeouts = cfg.GetTimeouts()
 	c.factory = kubernetes.NewFactoryGroup(k, cfg.GetObjectsResyncInterval(), cfg.GetPoliciesResyncInterval())
 	c.lister = kubernetes.NewListerGroup(c.factory)
 	c.events = eventsource.New[apis.StandSchedulePolicy](
@@ -57,11 +68,31 @@ func NewController(
 			DeleteFunc: c.delete,
 		},
 	)
+	c.deploys = eventsource.New[apps.Deployment](
+		c.factory.Core.Apps().V1().Deployments(),
+		eventsource.Handlers[apps.Deployment]{
+			AddFunc:    c.deploymentChanged,
+			UpdateFunc: func(_, obj *apps.Deployment) { c.deploymentChanged(obj) },
+			DeleteFunc: func(_ *apps.Deployment) {},
+		},
+	)
+	c.sts = eventsource.New[apps.StatefulSet](
+		c.factory.Core.Apps().V1().StatefulSets(),
+		eventsource.Handlers[apps.StatefulSet]{
+			AddFunc:    c.statefulSetChanged,
+			UpdateFunc: func(_, obj *apps.StatefulSet) { c.statefulSetChanged(obj) },
+			DeleteFunc: func(_ *apps.StatefulSet) {},
+		},
+	)
 	c.workers = []*worker.Worker{
 		worker.New(cfg.GetReconcilerConfig(), c.logger.Named("reconciler"), c.clock, c.reconcile),
 		worker.New(cfg.GetExecutorConfig(), c.logger.Named("executor"), c.clock, c.execute),
+		worker.New(cfg.GetEnforcerConfig(), c.logger.Named("enforcer"), c.clock, c.enforce),
+		worker.New(cfg.GetFinalizerConfig(), c.logger.Named("finalizer"), c.clock, c.finalize),
 	}
-	c.executor = executor.New(c.logger, az, c.kube, c.lister)
+	c.executor = executor.New(c.logger, az, c.kube, c.lister, c.timeouts).
+		WithCredentialsNamespace(cfg.CredentialsNamespace)
+	c.recorder = kubernetes.NewEventRecorder(c.kube, "stand-schedule-policy-controller")
 	return c
 }
 
@@ -106,6 +137,18 @@ func (c *Controller) enqueueReconcile(key string) {
 	c.workers[0].Enqueue(key)
 }
 
+func (c *Controller) enqueueReconcileAfter(key string, ts time.Duration) {
+	c.workers[0].EnqueueAfter(key, ts)
+}
+
 func (c *Controller) enqueueExecute(item WorkItem, ts time.Duration) {
 	c.workers[1].EnqueueAfter(item, ts)
 }
+
+func (c *Controller) enqueueEnforce(item EnforceItem) {
+	c.workers[2].Enqueue(item)
+}
+
+func (c *Controller) enqueueFinalize(item FinalizeItem, ts time.Duration) {
+	c.workers[3].EnqueueAfter(item, ts)
+}
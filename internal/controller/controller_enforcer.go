@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/executor"
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/state"
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+type (
+	EnforceItem struct {
+		policyName string
+		namespace  string
+	}
+)
+
+const (
+	_EnforceTimeout = time.Minute * 10
+)
+
+func (e EnforceItem) String() string {
+	return fmt.Sprintf("%s/%s", e.policyName, e.namespace)
+}
+
+func (c *Controller) deploymentChanged(obj *apps.Deployment) {
+	c.workloadChanged(obj.ObjectMeta, obj.Spec.Replicas)
+}
+
+func (c *Controller) statefulSetChanged(obj *apps.StatefulSet) {
+	c.workloadChanged(obj.ObjectMeta, obj.Spec.Replicas)
+}
+
+// workloadChanged enqueues enforcement for stopped policies, when workload scaled up in their namespaces.
+func (c *Controller) workloadChanged(m meta.ObjectMeta, replicas *int32) {
+	if executor.IsSkipped(m) || replicas == nil || *replicas <= executor.GetShutdownReplicas(m) {
+		return
+	}
+
+	for _, name := range c.state.GetEnforced(m.Namespace) {
+		c.logger.Info("Workload changed while policy is stopped",
+			zap.String("policy_name", name),
+			zap.String("namespace", m.Namespace),
+			zap.String("workload", m.Name))
+		c.enqueueEnforce(EnforceItem{policyName: name, namespace: m.Namespace})
+	}
+}
+
+// setEnforcement caches compiled namespace filter of policy, when its shutdown is enforced.
+func setEnforcement(ps *state.PolicyState, obj *apis.StandSchedulePolicy) {
+	if !obj.Spec.EnforceShutdown || obj.Spec.Suspend {
+		ps.SetEnforcedNamespaces(nil)
+		return
+	}
+	ps.SetEnforcedNamespaces(executor.NewNamespaceFilter(obj.Spec.TargetNamespaceFilter).Matches)
+}
+
+func (c *Controller) enforce(i interface{}) error {
+	item := i.(EnforceItem)
+
+	ps, exists := c.state.Get(item.policyName)
+	policy, err := c.lister.Stands.Get(item.policyName)
+	if errors.IsNotFound(err) || !exists {
+		c.logger.Warn("Skip enforcement of policy because it not exists", zap.String("policy_name", item.policyName))
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	queued := func() {
+		c.logger.Info("Queue enforcement of policy because of controller executions limit reached",
+			zap.String("policy_name", item.policyName),
+			zap.String("namespace", item.namespace))
+	}
+	if !c.acquireSlot(c.clock.Now().Add(_EnforceTimeout), queued) {
+		return fmt.Errorf("execution limit of controller not released in %s", _EnforceTimeout)
+	}
+	defer c.limiter.release()
+
+	// enforcement never interrupts startup or shutdown, they reconcile workloads anyway
+	ctx, exec, _ := c.running.tryStart(item.policyName, apis.StatusShutdown, c.clock.Now(), _EnforceTimeout)
+	if exec == nil {
+		return nil
+	}
+
+	// startup could be started after workload change
+	if !policy.Spec.EnforceShutdown || policy.Spec.Suspend || !ps.IsStopped() {
+		c.running.finish(item.policyName, exec)
+		return nil
+	}
+
+	c.logger.Info("Enforce shutdown of policy in namespace",
+		zap.String("policy_name", item.policyName),
+		zap.String("namespace", item.namespace))
+
+	err = c.executor.EnforceShutdown(ctx, policy, item.namespace)
+
+	if reason := c.running.finish(item.policyName, exec); reason != "" {
+		c.logger.Info("Enforcement of policy cancelled",
+			zap.String("policy_name", item.policyName),
+			zap.String("namespace", item.namespace),
+			zap.String("reason", reason))
+		return nil
+	}
+
+	if util.IsDegraded(err) {
+		c.logger.Warn("Enforced shutdown of policy with degraded result",
+			zap.String("policy_name", item.policyName),
+			zap.String("namespace", item.namespace),
+			zap.Error(err))
+		return nil
+	}
+
+	if err != nil {
+		c.logger.Error("Failed to enforce shutdown of policy",
+			zap.String("policy_name", item.policyName),
+			zap.String("namespace", item.namespace),
+			zap.Error(err))
+	}
+
+	return err
+}
@@ -1,40 +1,132 @@
 package controller
 
 import (
+	"fmt"
+	"time"
+
 	"go.uber.org/zap"
+	core "k8s.io/api/core/v1"
 
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/executor"
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/state"
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
 )
 
 func (c *Controller) add(obj *apis.StandSchedulePolicy) {
 	c.logger.Debug("Discovered policy object", zap.String("policy_name", obj.Name))
-	ps, err := state.NewPolicyState(&obj.Spec.Schedules)
+	ps, err := c.newPolicyState(obj)
 	if err != nil {
-		c.logger.Error("Policy object has invalid format", zap.String("policy_name", obj.Name), zap.Error(err))
+		c.reject(obj, err)
 		return
 	}
 
+	ps.SetStopped(isStoppedByCheckpoint(obj))
+
+	if len(obj.Spec.Resources.Azure) > 0 && !c.executor.IsAzureEnabled() {
+		c.logger.Warn("Policy references azure resources, but azure provider is disabled",
+			zap.String("policy_name", obj.Name))
+		c.recorder.Event(obj, core.EventTypeWarning, "ProviderDisabled", azure.ErrDisabled.Error())
+	}
+
 	c.logger.Info("Added policy object", zap.String("policy_name", obj.Name))
 	c.state.AddOrUpdate(obj.Name, ps)
 	c.enqueueReconcile(obj.Name)
+	c.resumeIfRequired(obj)
+}
+
+// isStoppedByCheckpoint checks that policy has completed shutdown checkpoint, it survives restart
+// and rejection of policy, so stand is still known as stopped.
+func isStoppedByCheckpoint(obj *apis.StandSchedulePolicy) bool {
+	cp := obj.Status.Checkpoint
+	return cp != nil && cp.Completed && cp.Type == apis.StatusShutdown
+}
+
+// resumeIfRequired enqueues execution interrupted by controller restart, it continues from checkpoint.
+// Queued executions have not started yet, so they are enqueued again by their status conditions.
+func (c *Controller) resumeIfRequired(obj *apis.StandSchedulePolicy) {
+	if obj.DeletionTimestamp != nil {
+		return
+	}
+
+	cp := obj.Status.Checkpoint
+	if cp != nil && !cp.Completed && !cp.Cancelled {
+		c.logger.Info("Resume interrupted execution of policy",
+			zap.String("policy_name", obj.Name),
+			zap.String("schedule_type", string(cp.Type)),
+			zap.Stringer("at", cp.FireTime.Time))
+
+		c.enqueueExecute(WorkItem{
+			policyName:   obj.Name,
+			scheduleType: cp.Type,
+			fireAt:       cp.FireTime.Time,
+		}, 0)
+	}
+
+	for st, fireAt := range getQueuedExecutions(obj.Status.Conditions) {
+		if cp != nil && cp.Type == st && cp.FireTime.Time.Equal(fireAt) {
+			continue
+		}
+
+		c.logger.Info("Resume queued execution of policy",
+			zap.String("policy_name", obj.Name),
+			zap.String("schedule_type", string(st)),
+			zap.Stringer("at", fireAt))
+
+		c.enqueueExecute(WorkItem{
+			policyName:   obj.Name,
+			scheduleType: st,
+			fireAt:       fireAt,
+		}, 0)
+	}
+}
+
+// getQueuedExecutions returns fire times of schedules, which waited for execution slot by status conditions.
+func getQueuedExecutions(conditions []apis.StatusCondition) map[apis.ConditionScheduleType]time.Time {
+	queued := map[apis.ConditionScheduleType]bool{}
+	for _, condition := range conditions {
+		if condition.Type == apis.ConditionQueued {
+			queued[condition.Status] = true
+		}
+	}
+
+	executions := map[apis.ConditionScheduleType]time.Time{}
+	for _, condition := range conditions {
+		if condition.Type == apis.ConditionScheduled && queued[condition.Status] {
+			executions[condition.Status] = condition.LastTransitionTime.Time
+		}
+	}
+	return executions
 }
 
 func (c *Controller) update(oldObj, newObj *apis.StandSchedulePolicy) {
 	c.logger.Info("Sync policy object with", zap.String("policy_name", newObj.Name))
-	oldState, err := state.NewPolicyState(&oldObj.Spec.Schedules)
+	newState, err := c.newPolicyState(newObj)
 	if err != nil {
-		c.logger.Error("Policy object has invalid format", zap.String("policy_name", oldObj.Name), zap.Error(err))
+		c.reject(newObj, err)
 		return
 	}
-	newState, err := state.NewPolicyState(&newObj.Spec.Schedules)
-	if err != nil {
-		c.logger.Error("Policy object has invalid format", zap.String("policy_name", newObj.Name), zap.Error(err))
-		return
+	// previous object could be rejected as invalid, so it has no state
+	oldState, oldErr := c.newPolicyState(oldObj)
+
+	switch {
+	case oldObj.DeletionTimestamp == nil && newObj.DeletionTimestamp != nil:
+		c.running.cancel(newObj.Name, "policy deleted")
+	case !oldObj.Spec.Suspend && newObj.Spec.Suspend:
+		c.running.cancel(newObj.Name, "policy suspended")
+	case oldObj.Generation != newObj.Generation:
+		c.running.cancel(newObj.Name, "policy changed")
 	}
 
-	if !oldState.ScheduleEquals(newState) {
+	if oldErr != nil || !oldState.ScheduleEquals(newState) {
+		if current, exists := c.state.Get(newObj.Name); exists {
+			newState.SetStopped(current.IsStopped())
+		} else {
+			newState.SetStopped(isStoppedByCheckpoint(newObj))
+		}
 		c.state.AddOrUpdate(newObj.Name, newState)
+	} else if current, exists := c.state.Get(newObj.Name); exists {
+		setEnforcement(current, newObj)
 	}
 
 	c.enqueueReconcile(newObj.Name)
@@ -42,6 +134,48 @@ func (c *Controller) update(oldObj, newObj *apis.StandSchedulePolicy) {
 
 func (c *Controller) delete(obj *apis.StandSchedulePolicy) {
 	c.logger.Info("Deleted policy object", zap.String("policy_name", obj.Name))
+	c.running.cancel(obj.Name, "policy deleted")
 	c.state.Delete(obj.Name)
 	c.enqueueReconcile(obj.Name)
 }
+
+// reject reports policy with invalid spec, which is ignored until it is fixed, so its state is removed
+// and in-flight executions are cancelled. Finalizer of deleted policy is released anyway, so policy is not left stuck.
+func (c *Controller) reject(obj *apis.StandSchedulePolicy, err error) {
+	c.logger.Error("Policy object has invalid format", zap.String("policy_name", obj.Name), zap.Error(err))
+	c.recorder.Event(obj, core.EventTypeWarning, "InvalidPolicy", err.Error())
+
+	c.running.cancel(obj.Name, "policy invalid")
+	c.state.Delete(obj.Name)
+
+	if obj.DeletionTimestamp != nil {
+		c.enqueueReconcile(obj.Name)
+	}
+}
+
+// validate checks policy spec, which is not covered by CRD schema.
+func (c *Controller) validate(obj *apis.StandSchedulePolicy) error {
+	if err := obj.Spec.Timeouts.Validate(c.timeouts); err != nil {
+		return fmt.Errorf("invalid timeouts: %w", err)
+	}
+	if err := obj.Spec.Resources.Azure.Validate(); err != nil {
+		return fmt.Errorf("invalid resources: %w", err)
+	}
+	if _, err := executor.NewStartupOrder(obj.Spec.StartupOrder); err != nil {
+		return fmt.Errorf("invalid startup order: %w", err)
+	}
+	return nil
+}
+
+// newPolicyState validates policy spec and creates state for its schedules.
+func (c *Controller) newPolicyState(obj *apis.StandSchedulePolicy) (*state.PolicyState, error) {
+	if err := c.validate(obj); err != nil {
+		return nil, err
+	}
+	ps, err := state.NewPolicyState(&obj.Spec.Schedules)
+	if err != nil {
+		return nil, err
+	}
+	setEnforcement(ps, obj)
+	return ps, nil
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+)
+
+type (
+	// execution contains in-flight execution of policy, which could be cancelled by conflicting event.
+	execution struct {
+		st     apis.ConditionScheduleType
+		fireAt time.Time
+		cancel context.CancelFunc
+		done   chan struct{}
+		reason string
+	}
+	executions struct {
+		lock sync.Mutex
+		data map[string]*execution
+	}
+)
+
+func newExecutions() *executions {
+	return &executions{
+		data: make(map[string]*execution),
+	}
+}
+
+// start cancels in-flight execution of policy, awaits its completion and registers new one.
+// In-flight execution of the same type (e.g. startup of resources with lead time) is awaited without cancellation.
+func (e *executions) start(
+	policyName string,
+	st apis.ConditionScheduleType,
+	fireAt time.Time,
+	timeout time.Duration,
+) (context.Context, *execution) {
+	for {
+		ctx, exec, running := e.tryStart(policyName, st, fireAt, timeout)
+		if exec != nil {
+			return ctx, exec
+		}
+
+		if running.st != st {
+			e.cancel(policyName, "superseded by "+string(st))
+		}
+		<-running.done
+	}
+}
+
+// tryStart registers new execution of policy, unless another one is in-flight.
+func (e *executions) tryStart(
+	policyName string,
+	st apis.ConditionScheduleType,
+	fireAt time.Time,
+	timeout time.Duration,
+) (context.Context, *execution, *execution) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if running, exists := e.data[policyName]; exists {
+		return nil, nil, running
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	exec := &execution{
+		st:     st,
+		fireAt: fireAt,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	e.data[policyName] = exec
+
+	return ctx, exec, nil
+}
+
+// finish releases execution of policy, returns cancellation reason if it was cancelled.
+func (e *executions) finish(policyName string, exec *execution) string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.data[policyName] == exec {
+		delete(e.data, policyName)
+	}
+	exec.cancel()
+	close(exec.done)
+
+	return exec.reason
+}
+
+// cancel interrupts in-flight execution of policy with specified reason.
+func (e *executions) cancel(policyName, reason string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	exec, exists := e.data[policyName]
+	if !exists {
+		return
+	}
+	if exec.reason == "" {
+		exec.reason = reason
+	}
+	exec.cancel()
+}
+
+// supersede cancels in-flight execution of policy of opposite type, which fired before specified time.
+// It is called outside of executor worker, so due execution is not queued behind the one it supersedes.
+func (e *executions) supersede(policyName string, st apis.ConditionScheduleType, fireAt time.Time) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	exec, exists := e.data[policyName]
+	if !exists || exec.st == st || !exec.fireAt.Before(fireAt) {
+		return false
+	}
+	if exec.reason == "" {
+		exec.reason = "superseded by " + string(st)
+	}
+	exec.cancel()
+	return true
+}
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+)
+
+var (
+	_FireAt = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func Test_ExecutionsTryStart(t *testing.T) {
+	cases := []struct {
+		name       string
+		running    []apis.ConditionScheduleType
+		st         apis.ConditionScheduleType
+		expStarted bool
+	}{
+		{name: "no running", st: apis.StatusShutdown, expStarted: true},
+		{name: "same type running", running: []apis.ConditionScheduleType{apis.StatusStartup}, st: apis.StatusStartup},
+		{name: "opposite type running", running: []apis.ConditionScheduleType{apis.StatusStartup}, st: apis.StatusShutdown},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			e := newExecutions()
+			for _, st := range tc.running {
+				e.tryStart("policy", st, _FireAt, time.Minute)
+			}
+
+			ctx, exec, running := e.tryStart("policy", tc.st, _FireAt, time.Minute)
+
+			assert.Equal(t, tc.expStarted, exec != nil)
+			assert.Equal(t, tc.expStarted, ctx != nil)
+			assert.Equal(t, tc.expStarted, running == nil)
+		})
+	}
+}
+
+func Test_ExecutionsStart(t *testing.T) {
+	cases := []struct {
+		name      string
+		running   apis.ConditionScheduleType
+		st        apis.ConditionScheduleType
+		expReason string
+	}{
+		{name: "opposite type cancelled", running: apis.StatusShutdown, st: apis.StatusStartup, expReason: "superseded by Startup"},
+		{name: "same type awaited", running: apis.StatusStartup, st: apis.StatusStartup, expReason: ""},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			e := newExecutions()
+			ctx, exec, _ := e.tryStart("policy", tc.running, _FireAt, time.Minute)
+
+			reasons := make(chan string, 1)
+			go func() {
+				// execution of the same type is not cancelled, so it completes on its own
+				if tc.expReason == "" {
+					time.Sleep(time.Millisecond * 10)
+				} else {
+					<-ctx.Done()
+				}
+				reasons <- e.finish("policy", exec)
+			}()
+
+			_, started := e.start("policy", tc.st, _FireAt, time.Minute)
+
+			assert.NotNil(t, started)
+			assert.Equal(t, tc.expReason, <-reasons)
+			assert.Equal(t, started, e.data["policy"])
+		})
+	}
+}
+
+func Test_ExecutionsCancel(t *testing.T) {
+	cases := []struct {
+		name      string
+		reasons   []string
+		expReason string
+	}{
+		{name: "not cancelled", expReason: ""},
+		{name: "cancelled", reasons: []string{"policy deleted"}, expReason: "policy deleted"},
+		{name: "first reason kept", reasons: []string{"policy changed", "policy deleted"}, expReason: "policy changed"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			e := newExecutions()
+			ctx, exec, _ := e.tryStart("policy", apis.StatusShutdown, _FireAt, time.Minute)
+
+			for _, reason := range tc.reasons {
+				e.cancel("policy", reason)
+			}
+
+			assert.Equal(t, len(tc.reasons) > 0, ctx.Err() != nil)
+			assert.Equal(t, tc.expReason, e.finish("policy", exec))
+			assert.Empty(t, e.data)
+			assert.Error(t, ctx.Err())
+		})
+	}
+}
+
+func Test_ExecutionsFinish(t *testing.T) {
+	e := newExecutions()
+	_, first, _ := e.tryStart("policy", apis.StatusShutdown, _FireAt, time.Minute)
+	_, other, _ := e.tryStart("other-policy", apis.StatusShutdown, _FireAt, time.Minute)
+
+	e.finish("policy", first)
+
+	// finished execution is released and awaiting ones are notified
+	_, ok := <-first.done
+	assert.False(t, ok)
+	assert.NotContains(t, e.data, "policy")
+	assert.Equal(t, other, e.data["other-policy"])
+}
+
+func Test_ExecutionsSupersede(t *testing.T) {
+	cases := []struct {
+		name          string
+		running       apis.ConditionScheduleType
+		st            apis.ConditionScheduleType
+		fireAt        time.Time
+		expSuperseded bool
+	}{
+		{name: "opposite fired later", running: apis.StatusShutdown, st: apis.StatusStartup, fireAt: _FireAt.Add(time.Minute), expSuperseded: true},
+		{name: "opposite fired earlier", running: apis.StatusShutdown, st: apis.StatusStartup, fireAt: _FireAt.Add(-time.Minute)},
+		{name: "same type", running: apis.StatusStartup, st: apis.StatusStartup, fireAt: _FireAt.Add(time.Minute)},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			e := newExecutions()
+			ctx, exec, _ := e.tryStart("policy", tc.running, _FireAt, time.Minute)
+
+			assert.Equal(t, tc.expSuperseded, e.supersede("policy", tc.st, tc.fireAt))
+			assert.Equal(t, tc.expSuperseded, ctx.Err() != nil)
+			assert.Equal(t, tc.expSuperseded, e.finish("policy", exec) != "")
+		})
+	}
+}
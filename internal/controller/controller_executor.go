@@ -6,39 +6,58 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/state"
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
 )
 
 // todo: check workitem.fireat and schedule.fireat
 // todo: validation webhook
-// todo: save state between reboots
 
 type (
 	WorkItem struct {
 		policyName   string
 		scheduleType apis.ConditionScheduleType
 		fireAt       time.Time
+		leadTime     time.Duration
 	}
 )
 
-const (
-	_ExecutionTimeout = time.Minute * 45
-	_DeadlineTimeout  = time.Minute * 91
-)
-
 func (w *WorkItem) String() string {
+	if w.leadTime > 0 {
+		return fmt.Sprintf("%s/%s at %s (lead time %s)", w.policyName, w.scheduleType, w.fireAt, w.leadTime)
+	}
 	return fmt.Sprintf("%s/%s at %s", w.policyName, w.scheduleType, w.fireAt)
 }
 
-func (w *WorkItem) deadline() time.Time {
-	return w.fireAt.Add(_DeadlineTimeout)
+func (w *WorkItem) deadline(timeouts apis.TimeoutsSpec) time.Time {
+	return w.fireAt.Add(timeouts.Deadline.Duration)
 }
 
 func (c *Controller) execute(i interface{}) error {
+	return c.executeItem(i.(WorkItem), false)
+}
+
+// executeItem runs schedule of policy, when it holds execution slot of controller, otherwise it waits for one.
+// Execution is checked again after wait, as policy could be changed, suspended or deleted meanwhile.
+func (c *Controller) executeItem(item WorkItem, acquired bool) error {
 	started := c.clock.Now()
-	item := i.(WorkItem)
+
+	ps, exists := c.state.Get(item.policyName)
+	policy, err := c.lister.Stands.Get(item.policyName)
+	if errors.IsNotFound(err) || !exists {
+		c.logger.Warn("Skip execution of policy because it not exists", zap.String("policy_name", item.policyName))
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	timeouts := policy.Spec.Timeouts.WithDefaults(c.timeouts)
 
 	if started.Before(item.fireAt) {
 		c.logger.Warn("Skip execution of policy because of current time before scheduled",
@@ -49,42 +68,115 @@ func (c *Controller) execute(i interface{}) error {
 		return nil
 	}
 
-	if started.After(item.deadline()) {
+	if started.After(item.deadline(timeouts)) {
 		c.logger.Warn("Skip execution of policy because of current time after deadline",
 			zap.String("policy_name", item.policyName),
 			zap.String("schedule_type", string(item.scheduleType)),
 			zap.Stringer("time", started),
-			zap.Stringer("scheduled_deadline", item.deadline()))
+			zap.Stringer("scheduled_deadline", item.deadline(timeouts)))
+
+		if item.leadTime <= 0 && c.failQueued(ps, item.scheduleType, started) {
+			c.enqueueReconcile(item.policyName)
+		}
 		return nil
 	}
 
-	state, exists := c.state.Get(item.policyName)
-	policy, err := c.lister.Stands.Get(item.policyName)
-	if errors.IsNotFound(err) || !exists {
-		c.logger.Warn("Skip execution of policy because it not exists", zap.String("policy_name", item.policyName))
+	if policy.Spec.Suspend {
+		c.logger.Info("Skip execution of policy because it is suspended",
+			zap.String("policy_name", item.policyName),
+			zap.String("schedule_type", string(item.scheduleType)))
+
+		// skipped schedule is reported as cancelled, so next one is scheduled
+		if item.leadTime <= 0 {
+			ps.UpdateStatus(item.scheduleType, started, util.NewCancelledError("policy suspended"))
+			c.enqueueReconcile(item.policyName)
+		}
 		return nil
 	}
 
-	if err != nil {
-		return err
+	if item.scheduleType != apis.StatusStartup && item.scheduleType != apis.StatusShutdown {
+		return fmt.Errorf("not supported schedule type specified: %s", item.scheduleType)
+	}
+
+	if !acquired {
+		queued := false
+		ok := c.acquireSlot(item.deadline(timeouts), func() {
+			c.logger.Info("Queue execution of policy because of controller executions limit reached",
+				zap.String("policy_name", item.policyName),
+				zap.String("schedule_type", string(item.scheduleType)))
+
+			queued = true
+			if item.leadTime <= 0 {
+				ps.WithSchedule(item.scheduleType, func(schedule *state.ScheduleState) {
+					schedule.SetQueued(started)
+				})
+				c.enqueueReconcile(item.policyName)
+			}
+		})
+		if !ok {
+			if item.leadTime <= 0 && c.failQueued(ps, item.scheduleType, c.clock.Now()) {
+				c.enqueueReconcile(item.policyName)
+			}
+			return nil
+		}
+		defer c.limiter.release()
+
+		if queued {
+			return c.executeItem(item, true)
+		}
+	}
+
+	if item.leadTime > 0 {
+		return c.executeLead(item, policy, timeouts)
+	}
+
+	dequeued := false
+	ps.WithSchedule(item.scheduleType, func(schedule *state.ScheduleState) {
+		if dequeued = schedule.IsQueued(); dequeued {
+			schedule.SetDequeued()
+		}
+	})
+	if dequeued {
+		c.enqueueReconcile(item.policyName)
 	}
 
 	c.logger.Info("Execute schedule of policy",
 		zap.String("policy_name", item.policyName),
 		zap.String("schedule_type", string(item.scheduleType)))
 
-	ctx, cancel := context.WithTimeout(context.Background(), _ExecutionTimeout)
-	defer cancel()
+	// startup and shutdown of policy are mutually exclusive, latest one cancels in-flight execution
+	ctx, exec := c.running.start(item.policyName, item.scheduleType, item.fireAt, timeouts.Execution.Duration)
 
 	switch item.scheduleType {
 	case apis.StatusShutdown:
-		err = c.executor.ExecuteShutdown(ctx, policy)
-		state.UpdateStatus(item.scheduleType, c.clock.Now(), err)
+		err = c.executor.ExecuteShutdown(ctx, policy, item.fireAt)
 	case apis.StatusStartup:
-		err = c.executor.ExecuteStartup(ctx, policy)
-		state.UpdateStatus(item.scheduleType, c.clock.Now(), err)
-	default:
-		err = fmt.Errorf("not supported schedule type specified: %s", item.scheduleType)
+		// stop enforcement before workloads scaled up by startup
+		ps.SetStopped(false)
+		err = c.executor.ExecuteStartup(ctx, policy, item.fireAt)
+	}
+
+	if reason := c.running.finish(item.policyName, exec); reason != "" {
+		err = util.NewCancelledError(reason)
+	}
+	ps.UpdateStatus(item.scheduleType, c.clock.Now(), err)
+
+	if util.IsCancelled(err) {
+		c.logger.Warn("Execution of policy cancelled",
+			zap.String("policy_name", item.policyName),
+			zap.String("schedule_type", string(item.scheduleType)),
+			zap.Error(err))
+
+		// cancelled execution is not resumed after restart, e.g. superseded shutdown is not replayed
+		return c.executor.CancelCheckpoint(context.Background(), policy, item.scheduleType, item.fireAt)
+	}
+
+	if util.IsDegraded(err) {
+		c.logger.Warn("Executed schedule of policy with degraded result",
+			zap.String("policy_name", item.policyName),
+			zap.String("schedule_type", string(item.scheduleType)),
+			zap.Error(err))
+		return nil
 	}
 
 	if err != nil {
@@ -100,3 +192,49 @@ func (c *Controller) execute(i interface{}) error {
 		zap.String("schedule_type", string(item.scheduleType)))
 	return nil
 }
+
+// executeLead starts azure resources with lead time, result is reported with events,
+// failed resources are attempted again by main startup, which reports them in schedule status.
+func (c *Controller) executeLead(item WorkItem, policy *apis.StandSchedulePolicy, timeouts apis.TimeoutsSpec) error {
+	c.logger.Info("Execute lead startup of policy",
+		zap.String("policy_name", item.policyName),
+		zap.Stringer("lead_time", item.leadTime))
+
+	// main startup awaits lead startup, while shutdown cancels it
+	ctx, exec := c.running.start(item.policyName, item.scheduleType, item.fireAt, timeouts.Execution.Duration)
+	err := c.executor.ExecuteLeadStartup(ctx, policy, item.leadTime)
+	if reason := c.running.finish(item.policyName, exec); reason != "" {
+		err = util.NewCancelledError(reason)
+	}
+
+	switch {
+	case err == nil:
+		c.recorder.Eventf(policy, core.EventTypeNormal, "LeadStartup",
+			"azure resources with lead time %s started", item.leadTime)
+		return nil
+	case util.IsCancelled(err):
+		c.recorder.Eventf(policy, core.EventTypeWarning, "LeadStartupCancelled",
+			"startup of azure resources with lead time %s cancelled: %s", item.leadTime, err)
+		return nil
+	default:
+		c.logger.Error("Failed to execute lead startup of policy",
+			zap.String("policy_name", item.policyName),
+			zap.Stringer("lead_time", item.leadTime),
+			zap.Error(err))
+		c.recorder.Eventf(policy, core.EventTypeWarning, "LeadStartupFailed",
+			"startup of azure resources with lead time %s failed: %s", item.leadTime, err)
+		return err
+	}
+}
+
+// failQueued marks queued schedule of policy failed, when execution slot is not acquired before deadline.
+func (c *Controller) failQueued(ps *state.PolicyState, st apis.ConditionScheduleType, at time.Time) bool {
+	failed := false
+	ps.WithSchedule(st, func(schedule *state.ScheduleState) {
+		if failed = schedule.IsQueued(); failed {
+			schedule.SetFailed(at)
+			schedule.SetMessage("execution limit of controller not released before deadline")
+		}
+	})
+	return failed
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+type (
+	// FinalizeItem is action of deleted policy, it is performed by dedicated worker, so it doesn't block schedules.
+	FinalizeItem struct {
+		policyName string
+		action     apis.ConditionScheduleType
+		deletedAt  time.Time
+	}
+)
+
+const (
+	_Finalizer               = apis.AnnotationPrefix + "/restore"
+	_DefaultFinalizerTimeout = time.Minute * 30
+	_FinalizeRetryInterval   = time.Minute
+)
+
+func (f FinalizeItem) String() string {
+	return fmt.Sprintf("%s/%s deleted at %s", f.policyName, f.action, f.deletedAt)
+}
+
+// ensureFinalizer adds or removes policy finalizer according to spec, returns updated policy.
+func (c *Controller) ensureFinalizer(policy *apis.StandSchedulePolicy) (*apis.StandSchedulePolicy, error) {
+	enabled := policy.Spec.Finalizer.Enabled
+	if enabled == hasFinalizer(policy) {
+		return policy, nil
+	}
+
+	c.logger.Info("Update policy finalizer",
+		zap.String("policy_name", policy.Name),
+		zap.Bool("enabled", enabled))
+
+	policy = policy.DeepCopy()
+	if enabled {
+		policy.Finalizers = append(policy.Finalizers, _Finalizer)
+	} else {
+		policy.Finalizers = util.Where(policy.Finalizers, func(_ int, f string) bool {
+			return f != _Finalizer
+		})
+	}
+
+	return c.kube.StandSchedulesClient().
+		StandSchedulesV1().
+		StandSchedulePolicies().
+		Update(context.Background(), policy, meta.UpdateOptions{})
+}
+
+// scheduleFinalize enqueues finalizer action for deleted policy.
+func (c *Controller) scheduleFinalize(policy *apis.StandSchedulePolicy) {
+	if !hasFinalizer(policy) {
+		return
+	}
+
+	action := getFinalizerAction(policy)
+
+	c.logger.Info("Schedule finalizer of deleted policy",
+		zap.String("policy_name", policy.Name),
+		zap.String("schedule_type", string(action)))
+
+	c.enqueueFinalize(FinalizeItem{
+		policyName: policy.Name,
+		action:     action,
+		deletedAt:  policy.DeletionTimestamp.Time,
+	}, 0)
+}
+
+// finalize performs finalizer action and releases finalizer, when action succeeded or timeout passed.
+// Failed action is retried by finalizer itself until timeout, so finalizer is not left after worker retries.
+func (c *Controller) finalize(i interface{}) error {
+	item := i.(FinalizeItem)
+
+	policy, err := c.lister.Stands.Get(item.policyName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !hasFinalizer(policy) {
+		return nil
+	}
+
+	timeout := policy.Spec.Finalizer.Timeout.Duration
+	if timeout <= 0 {
+		timeout = _DefaultFinalizerTimeout
+	}
+	deadline := item.deletedAt.Add(timeout)
+
+	queued := func() {
+		c.logger.Info("Queue finalizer of policy because of controller executions limit reached",
+			zap.String("policy_name", item.policyName),
+			zap.String("schedule_type", string(item.action)))
+	}
+
+	// action of invalid policy is not performed, as its spec is never executed
+	invalid := c.validate(policy)
+	if invalid != nil {
+		c.recorder.Eventf(policy, core.EventTypeWarning, "FinalizeSkipped",
+			"%s not performed before deletion of invalid policy, finalizer released: %s", item.action, invalid)
+	} else if c.clock.Now().Before(deadline) && c.acquireSlot(deadline, queued) {
+		c.logger.Info("Execute finalizer of policy",
+			zap.String("policy_name", item.policyName),
+			zap.String("schedule_type", string(item.action)))
+
+		timeout := util.Min(deadline.Sub(c.clock.Now()), policy.Spec.Timeouts.WithDefaults(c.timeouts).Execution.Duration)
+		ctx, exec := c.running.start(item.policyName, item.action, item.deletedAt, timeout)
+
+		switch item.action {
+		case apis.StatusShutdown:
+			err = c.executor.ExecuteShutdown(ctx, policy, item.deletedAt)
+		default:
+			err = c.executor.ExecuteStartup(ctx, policy, item.deletedAt)
+		}
+
+		if reason := c.running.finish(item.policyName, exec); reason != "" {
+			err = util.NewCancelledError(reason)
+		}
+		c.limiter.release()
+
+		now := c.clock.Now()
+		switch {
+		case err == nil:
+			c.recorder.Eventf(policy, core.EventTypeNormal, "Finalized",
+				"%s performed before policy deletion", item.action)
+		case util.IsDegraded(err):
+			c.recorder.Eventf(policy, core.EventTypeWarning, "Finalized",
+				"%s performed before policy deletion with degraded result: %s", item.action, err)
+		case !shouldReleaseFinalizer(err, now, deadline):
+			c.recorder.Eventf(policy, core.EventTypeWarning, "FinalizeFailed",
+				"%s failed before policy deletion, will retry: %s", item.action, err)
+			c.enqueueFinalize(item, getFinalizeRetryDelay(now, deadline))
+			return nil
+		default:
+			c.recorder.Eventf(policy, core.EventTypeWarning, "FinalizeTimeout",
+				"%s failed before policy deletion, finalizer released: %s", item.action, err)
+		}
+	} else {
+		c.recorder.Eventf(policy, core.EventTypeWarning, "FinalizeTimeout",
+			"%s not performed before policy deletion in %s, finalizer released", item.action, timeout)
+	}
+
+	c.logger.Info("Release finalizer of policy", zap.String("policy_name", item.policyName))
+
+	policy = policy.DeepCopy()
+	policy.Finalizers = util.Where(policy.Finalizers, func(_ int, f string) bool {
+		return f != _Finalizer
+	})
+
+	_, err = c.kube.StandSchedulesClient().
+		StandSchedulesV1().
+		StandSchedulePolicies().
+		Update(context.Background(), policy, meta.UpdateOptions{})
+
+	return err
+}
+
+// shouldReleaseFinalizer checks that finalizer action performed, or it is not retried anymore after deadline.
+func shouldReleaseFinalizer(err error, now, deadline time.Time) bool {
+	return err == nil || util.IsDegraded(err) || !now.Before(deadline)
+}
+
+// getFinalizeRetryDelay returns delay before next attempt of failed action, last attempt is made at deadline,
+// so finalizer is released in time.
+func getFinalizeRetryDelay(now, deadline time.Time) time.Duration {
+	return util.Min(_FinalizeRetryInterval, deadline.Sub(now))
+}
+
+func hasFinalizer(policy *apis.StandSchedulePolicy) bool {
+	for _, f := range policy.Finalizers {
+		if f == _Finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func getFinalizerAction(policy *apis.StandSchedulePolicy) apis.ConditionScheduleType {
+	if policy.Spec.Finalizer.Action == apis.StatusShutdown {
+		return apis.StatusShutdown
+	}
+	return apis.StatusStartup
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+func Test_ShouldReleaseFinalizer(t *testing.T) {
+	deadline := _FireAt.Add(_DefaultFinalizerTimeout)
+
+	cases := []struct {
+		name       string
+		err        error
+		now        time.Time
+		expRelease bool
+	}{
+		{name: "succeeded", now: _FireAt, expRelease: true},
+		{name: "degraded", err: util.NewDegradedError(errors.New("timed out")), now: _FireAt, expRelease: true},
+		{name: "failed before deadline", err: errors.New("failed"), now: _FireAt, expRelease: false},
+		{name: "cancelled before deadline", err: util.NewCancelledError("policy changed"), now: _FireAt, expRelease: false},
+		{name: "failed at deadline", err: errors.New("failed"), now: deadline, expRelease: true},
+		{name: "failed after deadline", err: errors.New("failed"), now: deadline.Add(time.Minute), expRelease: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expRelease, shouldReleaseFinalizer(tc.err, tc.now, deadline))
+		})
+	}
+}
+
+func Test_GetFinalizeRetryDelay(t *testing.T) {
+	deadline := _FireAt.Add(_DefaultFinalizerTimeout)
+
+	cases := []struct {
+		name     string
+		now      time.Time
+		expDelay time.Duration
+	}{
+		{name: "retry interval", now: _FireAt, expDelay: _FinalizeRetryInterval},
+		{name: "last attempt at deadline", now: deadline.Add(-time.Second * 10), expDelay: time.Second * 10},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expDelay, getFinalizeRetryDelay(tc.now, deadline))
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+type (
+	// limiter bounds simultaneous startups, shutdowns, finalizers and enforcements of all policies,
+	// executions over the limit wait for free slot in order of arrival.
+	limiter struct {
+		lock    sync.Mutex
+		limit   int
+		used    int
+		waiters []chan struct{}
+	}
+)
+
+func newLimiter(limit int) *limiter {
+	return &limiter{limit: limit}
+}
+
+// acquire takes execution slot, when all slots are taken it calls queued and waits until slot is handed over
+// by release or context is done.
+func (l *limiter) acquire(ctx context.Context, queued func()) error {
+	if l.limit <= 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	if l.used < l.limit && len(l.waiters) == 0 {
+		l.used++
+		l.lock.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.lock.Unlock()
+
+	if queued != nil {
+		queued()
+	}
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	select {
+	case <-ready:
+		// slot handed over together with cancellation, so it is passed to next waiter
+		l.handOver()
+	default:
+		l.waiters = util.Where(l.waiters, func(_ int, w chan struct{}) bool {
+			return w != ready
+		})
+	}
+	return ctx.Err()
+}
+
+func (l *limiter) release() {
+	if l.limit <= 0 {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.handOver()
+}
+
+// handOver passes released slot to the first waiter or frees it, when nobody waits.
+func (l *limiter) handOver() {
+	if len(l.waiters) == 0 {
+		l.used--
+		return
+	}
+
+	next := l.waiters[0]
+	l.waiters = l.waiters[1:]
+	close(next)
+}
+
+// acquireSlot waits for free execution slot of controller until deadline, queued is called when execution has to wait.
+func (c *Controller) acquireSlot(deadline time.Time, queued func()) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), deadline.Sub(c.clock.Now()))
+	defer cancel()
+	return c.limiter.acquire(ctx, queued) == nil
+}
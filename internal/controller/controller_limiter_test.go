@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+)
+
+func Test_LimiterAcquire(t *testing.T) {
+	cases := []struct {
+		name      string
+		limit     int
+		acquired  int
+		expQueued bool
+	}{
+		{name: "no limit", limit: 0, acquired: 10},
+		{name: "free slot", limit: 2, acquired: 1},
+		{name: "all slots taken", limit: 2, acquired: 2, expQueued: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			l := newLimiter(tc.limit)
+			for i := 0; i < tc.acquired; i++ {
+				assert.NoError(t, l.acquire(context.Background(), nil))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+			defer cancel()
+
+			queued := false
+			err := l.acquire(ctx, func() { queued = true })
+
+			assert.Equal(t, tc.expQueued, queued)
+			assert.Equal(t, tc.expQueued, err != nil)
+			// cancelled waiter leaves the queue
+			assert.Empty(t, l.waiters)
+		})
+	}
+}
+
+func Test_LimiterFairness(t *testing.T) {
+	l := newLimiter(1)
+	assert.NoError(t, l.acquire(context.Background(), nil))
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		queued := make(chan struct{})
+		go func(i int) {
+			_ = l.acquire(context.Background(), func() { close(queued) })
+			order <- i
+			l.release()
+		}(i)
+		<-queued
+	}
+
+	l.release()
+
+	// waiters take released slot in order of arrival
+	assert.Equal(t, 0, <-order)
+	assert.Equal(t, 1, <-order)
+	assert.Equal(t, 2, <-order)
+	assert.NoError(t, l.acquire(context.Background(), func() { t.Fatal("slot is not released") }))
+}
+
+func Test_GetQueuedExecutions(t *testing.T) {
+	fireAt := meta.NewTime(_FireAt)
+
+	cases := []struct {
+		name       string
+		conditions []apis.StatusCondition
+		exp        map[apis.ConditionScheduleType]time.Time
+	}{
+		{
+			name: "scheduled only",
+			conditions: []apis.StatusCondition{
+				{Type: apis.ConditionScheduled, Status: apis.StatusShutdown, LastTransitionTime: fireAt},
+			},
+			exp: map[apis.ConditionScheduleType]time.Time{},
+		},
+		{
+			name: "queued",
+			conditions: []apis.StatusCondition{
+				{Type: apis.ConditionScheduled, Status: apis.StatusShutdown, LastTransitionTime: fireAt},
+				{Type: apis.ConditionQueued, Status: apis.StatusShutdown, LastTransitionTime: meta.NewTime(_FireAt.Add(time.Minute))},
+				{Type: apis.ConditionScheduled, Status: apis.StatusStartup, LastTransitionTime: fireAt},
+			},
+			exp: map[apis.ConditionScheduleType]time.Time{apis.StatusShutdown: _FireAt},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.exp, getQueuedExecutions(tc.conditions))
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/state"
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
 )
@@ -21,16 +22,32 @@ func (c *Controller) reconcile(i interface{}) error {
 		return nil
 	}
 
+	// deleted policy is finalized, even when it is rejected as invalid and has no state
+	if policy.DeletionTimestamp != nil {
+		c.scheduleFinalize(policy)
+	}
+
 	ps, exists := c.state.Get(policy.Name)
 	if !exists {
 		c.logger.Info("Deleted policy removed from execution", zap.String("policy_name", policy.Name))
 		return nil
 	}
 
-	c.scheduleIfRequired(policy, ps)
+	if policy.DeletionTimestamp == nil {
+		policy, err = c.ensureFinalizer(policy)
+		if err != nil {
+			c.logger.Error("Failed to update policy finalizer",
+				zap.String("policy_name", policyName),
+				zap.Error(err))
+			return err
+		}
+
+		c.supersedeIfRequired(policy, ps)
+		c.scheduleIfRequired(policy, ps)
+	}
 
 	c.logger.Info("Update policy status", zap.String("policy_name", policy.Name))
-	policy.Status.UpdateConditions(ps.GetConditions())
+	policy.Status.UpdateConditions(append(ps.GetConditions(), c.getProviderConditions(policy)...))
 
 	_, err = c.kube.StandSchedulesClient().
 		StandSchedulesV1().
@@ -46,45 +63,140 @@ func (c *Controller) reconcile(i interface{}) error {
 	return err
 }
 
+// getProviderConditions reports that azure resources of policy are left as is, while azure provider is disabled.
+func (c *Controller) getProviderConditions(policy *apis.StandSchedulePolicy) []apis.StatusCondition {
+	if len(policy.Spec.Resources.Azure) == 0 || c.executor.IsAzureEnabled() {
+		return nil
+	}
+
+	var conditions []apis.StatusCondition
+	for _, st := range []apis.ConditionScheduleType{apis.StatusStartup, apis.StatusShutdown} {
+		since := meta.NewTime(c.clock.Now())
+		for _, prev := range policy.Status.Conditions {
+			if prev.Type == apis.ConditionProviderDisabled && prev.Status == st {
+				since = prev.LastTransitionTime
+			}
+		}
+
+		conditions = append(conditions, apis.StatusCondition{
+			Type:               apis.ConditionProviderDisabled,
+			Status:             st,
+			LastTransitionTime: since,
+			Reason:             "AzureDisabled",
+			Message:            azure.ErrDisabled.Error(),
+		})
+	}
+	return conditions
+}
+
 func (c *Controller) scheduleIfRequired(policy *apis.StandSchedulePolicy, ps *state.PolicyState) {
 	ts := c.clock.Now()
 
-	if ps.ScheduleRequired(apis.StatusShutdown, ts) {
-		c.schedule(ts, policy.Name, apis.StatusShutdown, ps.GetSchedule(apis.StatusShutdown))
+	for _, st := range []apis.ConditionScheduleType{apis.StatusShutdown, apis.StatusStartup} {
+		var (
+			required bool
+			fireAt   time.Time
+		)
+		ps.WithSchedule(st, func(schedule *state.ScheduleState) {
+			if required = schedule.ScheduleRequired(ts); !required {
+				return
+			}
+			schedule.SetFiredAfter(ts)
+			if !schedule.GetFireTime().IsZero() {
+				fireAt = getFireTime(policy, schedule)
+			}
+		})
+
+		if required {
+			c.schedule(ts, policy, st, fireAt)
+		}
 	}
+}
 
-	if ps.ScheduleRequired(apis.StatusStartup, ts) {
-		c.schedule(ts, policy.Name, apis.StatusStartup, ps.GetSchedule(apis.StatusStartup))
+// supersedeIfRequired cancels in-flight execution of policy, when opposite schedule fired after it,
+// as fired execution waits for executor worker, which could be occupied by execution it should supersede.
+func (c *Controller) supersedeIfRequired(policy *apis.StandSchedulePolicy, ps *state.PolicyState) {
+	ts := c.clock.Now()
+
+	for _, st := range []apis.ConditionScheduleType{apis.StatusShutdown, apis.StatusStartup} {
+		var fireAt time.Time
+		ps.WithSchedule(st, func(schedule *state.ScheduleState) {
+			if !schedule.GetFireTime().IsZero() && schedule.GetExecutedTime().IsZero() {
+				fireAt = getFireTime(policy, schedule)
+			}
+		})
+
+		if fireAt.IsZero() || fireAt.After(ts) {
+			continue
+		}
+
+		if c.running.supersede(policy.Name, st, fireAt) {
+			c.logger.Info("Cancel execution of policy superseded by fired schedule",
+				zap.String("policy_name", policy.Name),
+				zap.String("schedule_type", string(st)),
+				zap.Stringer("at", fireAt))
+		}
 	}
 }
 
 func (c *Controller) schedule(
 	ts time.Time,
-	policyName string,
+	policy *apis.StandSchedulePolicy,
 	scheduleType apis.ConditionScheduleType,
-	schedule *state.ScheduleState,
+	fireAt time.Time,
 ) {
-	schedule.SetFiredAfter(ts)
-
-	if schedule.GetFireTime().IsZero() {
+	if fireAt.IsZero() {
 		c.logger.Error("Failed to schedule policy",
-			zap.String("policy_name", policyName),
+			zap.String("policy_name", policy.Name),
 			zap.String("schedule_type", string(scheduleType)),
 			zap.Stringer("since", ts))
 		return
 	}
 
 	c.logger.Info("Schedule policy",
-		zap.String("policy_name", policyName),
+		zap.String("policy_name", policy.Name),
 		zap.String("schedule_type", string(scheduleType)),
 		zap.Stringer("since", ts),
-		zap.Stringer("at", schedule.GetFireTime()))
+		zap.Stringer("at", fireAt))
 
 	item := WorkItem{
-		policyName:   policyName,
+		policyName:   policy.Name,
 		scheduleType: scheduleType,
-		fireAt:       schedule.GetFireTime(),
+		fireAt:       fireAt,
+	}
+
+	c.enqueueExecute(item, fireAt.Sub(ts))
+	// fired schedule supersedes in-flight execution of opposite type
+	c.enqueueReconcileAfter(policy.Name, fireAt.Sub(ts))
+
+	if scheduleType != apis.StatusStartup || !c.executor.IsAzureEnabled() {
+		return
+	}
+
+	// external resources with lead time are started ahead, so they are ready at startup time
+	for _, leadTime := range policy.Spec.Resources.Azure.GetLeadTimes() {
+		lead := WorkItem{
+			policyName:   policy.Name,
+			scheduleType: scheduleType,
+			fireAt:       fireAt.Add(-leadTime),
+			leadTime:     leadTime,
+		}
+
+		c.logger.Info("Schedule lead startup of policy",
+			zap.String("policy_name", policy.Name),
+			zap.Stringer("lead_time", leadTime),
+			zap.Stringer("at", lead.fireAt))
+
+		c.enqueueExecute(lead, lead.fireAt.Sub(ts))
 	}
+}
 
-	c.enqueueExecute(item, schedule.GetFireTime().Sub(ts))
+// getFireTime returns time of scheduled execution of policy, policies with the same cron are spread with jitter,
+// while on demand overrides are executed in time.
+func getFireTime(policy *apis.StandSchedulePolicy, schedule *state.ScheduleState) time.Time {
+	fireAt := schedule.GetFireTime()
+	if !schedule.IsOverride() {
+		fireAt = fireAt.Add(policy.GetJitter())
+	}
+	return fireAt
 }
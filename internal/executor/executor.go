@@ -2,9 +2,12 @@ package executor
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/kubernetes"
@@ -13,32 +16,126 @@ import (
 
 type (
 	Executor struct {
-		logger *zap.Logger
-		azure  azure.Interface
-		kube   kubernetes.Interface
-		lister *kubernetes.ListerGroup
+		logger   *zap.Logger
+		azure    azure.Factory
+		kube     kubernetes.Interface
+		lister   *kubernetes.ListerGroup
+		timeouts apis.TimeoutsSpec
+		// credentialsNamespace restricts secrets referenced by policies, which are cluster scoped
+		credentialsNamespace string
 	}
 )
 
-func New(l *zap.Logger, az azure.Interface, k kubernetes.Interface, lister *kubernetes.ListerGroup) *Executor {
+func New(
+	l *zap.Logger,
+	az azure.Factory,
+	k kubernetes.Interface,
+	lister *kubernetes.ListerGroup,
+	timeouts apis.TimeoutsSpec,
+) *Executor {
 	return &Executor{
-		logger: l.Named("executor"),
-		azure:  az,
-		kube:   k,
-		lister: lister,
+		logger:   l.Named("executor"),
+		azure:    az,
+		kube:     k,
+		lister:   lister,
+		timeouts: timeouts,
 	}
 }
 
-func (ex *Executor) ExecuteShutdown(ctx context.Context, policy *apis.StandSchedulePolicy) error {
-	return multierr.Combine(
-		ex.executeShutdownKube(ctx, policy),
-		ex.executeShutdownAzure(ctx, policy.Spec.Resources.Azure),
+// WithCredentialsNamespace sets the only namespace, which azure credentials of policies are read from.
+func (ex *Executor) WithCredentialsNamespace(namespace string) *Executor {
+	ex.credentialsNamespace = namespace
+	return ex
+}
+
+// ExecuteShutdown stops policy resources, skipping steps completed by previous attempt of the same schedule.
+func (ex *Executor) ExecuteShutdown(ctx context.Context, policy *apis.StandSchedulePolicy, fireAt time.Time) error {
+	cp := ex.newCheckpoint(policy, apis.StatusShutdown, fireAt)
+	err := multierr.Combine(
+		ex.executeShutdownKube(ctx, policy, cp),
+		ex.executeShutdownAzure(ctx, policy, policy.Spec.Resources.Azure, cp),
 	)
+	return cp.Finish(ctx, err)
 }
 
-func (ex *Executor) ExecuteStartup(ctx context.Context, policy *apis.StandSchedulePolicy) error {
-	return multierr.Combine(
-		ex.executeStartupAzure(ctx, policy.Spec.Resources.Azure),
-		ex.executeStartupKube(ctx, policy),
+// ExecuteStartup starts policy resources, skipping steps completed by previous attempt of the same schedule.
+// Azure resources with lead time are included, so ones failed by lead startup are attempted again and
+// reported in startup status, while already started ones are skipped.
+func (ex *Executor) ExecuteStartup(ctx context.Context, policy *apis.StandSchedulePolicy, fireAt time.Time) error {
+	cp := ex.newCheckpoint(policy, apis.StatusStartup, fireAt)
+	err := multierr.Combine(
+		ex.executeStartupAzure(ctx, policy, policy.Spec.Resources.Azure, cp),
+		ex.executeStartupKube(ctx, policy, cp),
 	)
+	return cp.Finish(ctx, err)
+}
+
+// CancelCheckpoint marks checkpoint of cancelled execution, so it is not resumed after controller restart.
+func (ex *Executor) CancelCheckpoint(
+	ctx context.Context,
+	policy *apis.StandSchedulePolicy,
+	st apis.ConditionScheduleType,
+	fireAt time.Time,
+) error {
+	return ex.updateStatus(ctx, policy.Name, func(status *apis.StandSchedulePolicyStatus) {
+		if cp := status.Checkpoint; isCheckpointOf(cp, st, fireAt) && !cp.Completed {
+			cp.Cancelled = true
+		}
+	})
+}
+
+// ExecuteLeadStartup starts azure resources with specified lead time, ahead of main startup.
+func (ex *Executor) ExecuteLeadStartup(ctx context.Context, policy *apis.StandSchedulePolicy, leadTime time.Duration) error {
+	return ex.executeStartupAzure(ctx, policy, policy.Spec.Resources.Azure.WithLeadTime(leadTime), nil)
+}
+
+// EnforceShutdown scales down again workloads changed in namespace while stand is stopped.
+func (ex *Executor) EnforceShutdown(ctx context.Context, policy *apis.StandSchedulePolicy, namespace string) error {
+	return ex.enforceShutdownKube(ctx, policy, namespace)
+}
+
+// IsAzureEnabled returns true, when azure provider is configured in controller.
+func (ex *Executor) IsAzureEnabled() bool {
+	return ex.azure != nil
+}
+
+// updateStatus applies update to the latest version of policy status.
+func (ex *Executor) updateStatus(
+	ctx context.Context,
+	policyName string,
+	update func(status *apis.StandSchedulePolicyStatus),
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		policy, err := ex.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			Get(ctx, policyName, meta.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		update(&policy.Status)
+
+		_, err = ex.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			UpdateStatus(ctx, policy, meta.UpdateOptions{})
+		return err
+	})
+}
+
+// getStartupOrder returns compiled startup order filters of policy, they are compiled on each execution,
+// as policy has only a few of them.
+func (ex *Executor) getStartupOrder(policy *apis.StandSchedulePolicy) StartupOrder {
+	// invalid filters are rejected by policy validation, so state of such policy is removed and it is never executed
+	order, err := NewStartupOrder(policy.Spec.StartupOrder)
+	if err != nil {
+		ex.logger.Warn("Policy has invalid startup order", zap.String("policy_name", policy.Name), zap.Error(err))
+	}
+	return order
+}
+
+// getTimeouts returns policy timeouts merged with controller defaults.
+func (ex *Executor) getTimeouts(policy *apis.StandSchedulePolicy) apis.TimeoutsSpec {
+	return policy.Spec.Timeouts.WithDefaults(ex.timeouts)
 }
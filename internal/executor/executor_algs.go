@@ -1,32 +1,93 @@
 package executor
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/dlclark/regexp2"
 
+	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
 	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
 )
 
+type (
+	// AppsWave contains deployments and statefulSets with same startup order.
+	AppsWave struct {
+		Order        int
+		Deployments  []*apps.Deployment
+		StatefulSets []*apps.StatefulSet
+	}
+
+	// KeptApps contains workloads with pods which are kept running on shutdown.
+	KeptApps struct {
+		Pods      int64
+		selectors []labels.Selector
+	}
+
+	// Snapshot contains workloads state captured before shutdown, used as restore source on startup.
+	Snapshot struct {
+		Deployments  map[string]int32             `json:"deployments,omitempty"`
+		StatefulSets map[string]int32             `json:"statefulSets,omitempty"`
+		DaemonSets   map[string]map[string]string `json:"daemonSets,omitempty"`
+		Autoscalers  map[string]AutoscalerBounds  `json:"autoscalers,omitempty"`
+		CronJobs     []string                     `json:"cronJobs,omitempty"`
+		existing     map[string]bool
+	}
+
+	// StartupOrder contains compiled startup order filters of policy.
+	StartupOrder []*regexp2.Regexp
+
+	// NamespaceFilter contains compiled alternatives of policy namespace filter.
+	NamespaceFilter []*regexp2.Regexp
+
+	// AutoscalerBounds contains replicas bounds of horizontal pod autoscaler.
+	AutoscalerBounds struct {
+		MinReplicas *int32 `json:"minReplicas,omitempty"`
+		MaxReplicas int32  `json:"maxReplicas"`
+	}
+)
+
 func FilterAndSortNamespaces(
 	objects []*core.Namespace,
 	filter string,
 	reverse bool,
 ) []string {
-	var (
-		namespaces []string
-		filters    = strings.Split(filter, "|")
-	)
+	var namespaces []string
 
+	regs := NewNamespaceFilter(filter)
 	if reverse {
-		filters = util.Reverse(filters)
+		regs = util.Reverse(regs)
 	}
 
-	for _, f := range filters {
+	for _, reg := range regs {
+		for _, namespace := range objects {
+			matched, _ := reg.MatchString(namespace.Name)
+
+			if matched {
+				namespaces = append(namespaces, namespace.Name)
+			}
+		}
+	}
+
+	return namespaces
+}
+
+// NewNamespaceFilter compiles policy namespace filter, invalid alternatives are ignored.
+func NewNamespaceFilter(filter string) NamespaceFilter {
+	var regs NamespaceFilter
+
+	for _, f := range strings.Split(filter, "|") {
 		if f == "" {
 			continue
 		}
@@ -37,18 +98,29 @@ func FilterAndSortNamespaces(
 			continue
 		}
 
-		for _, namespace := range objects {
-			matched, _ := reg.MatchString(namespace.Name)
+		regs = append(regs, reg)
+	}
 
-			if matched {
-				namespaces = append(namespaces, namespace.Name)
-			}
+	return regs
+}
+
+// Matches checks that namespace matched by any alternative of filter.
+func (f NamespaceFilter) Matches(namespace string) bool {
+	for _, reg := range f {
+		if matched, _ := reg.MatchString(namespace); matched {
+			return true
 		}
 	}
+	return false
+}
 
-	return namespaces
+// IsNamespaceMatched checks that namespace matched by policy namespace filter.
+func IsNamespaceMatched(namespace, filter string) bool {
+	return NewNamespaceFilter(filter).Matches(namespace)
 }
 
+// FilterAndMergeAzureResources adds resources matched by filter to group of its priority. Resource already selected
+// by previous filter is skipped, so overlapping filters don't operate the same resource twice.
 func FilterAndMergeAzureResources(
 	result map[int64][]*azure.Resource,
 	list []*azure.Resource,
@@ -60,11 +132,481 @@ func FilterAndMergeAzureResources(
 		return
 	}
 
+	selected := map[string]bool{}
+	for _, group := range result {
+		for _, resource := range group {
+			selected[resource.GetID()] = true
+		}
+	}
+
 	for _, resource := range list {
+		if selected[resource.GetID()] {
+			continue
+		}
+
 		match, _ := reg.MatchString(resource.GetName())
 
-		if match {
+		if match && filter.TagSelector.Matches(resource.GetTags()) {
+			selected[resource.GetID()] = true
+			resource.WithWait(filter.Wait, filter.WaitTimeout.Duration)
 			result[filter.Priority] = append(result[filter.Priority], resource)
 		}
 	}
 }
+
+// GetAzurePriorities returns distinct priorities of resources entries in order of entries.
+func GetAzurePriorities(filters apis.AzureResourceList) []int64 {
+	seen := map[int64]bool{}
+	var priorities []int64
+	for _, filter := range filters {
+		if !seen[filter.Priority] {
+			seen[filter.Priority] = true
+			priorities = append(priorities, filter.Priority)
+		}
+	}
+	return priorities
+}
+
+// GetUnselectedAzureResources returns ids of resources in policy status, which are no longer selected by policy.
+func GetUnselectedAzureResources(
+	statuses map[string]apis.AzureResourceStatus,
+	selected map[int64][]*azure.Resource,
+) []string {
+	ids := map[string]bool{}
+	for _, group := range selected {
+		for _, resource := range group {
+			ids[resource.GetID()] = true
+		}
+	}
+
+	var unselected []string
+	for id := range statuses {
+		if !ids[id] {
+			unselected = append(unselected, id)
+		}
+	}
+	sort.Strings(unselected)
+	return unselected
+}
+
+func IsPodTerminated(pod *core.Pod) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Terminated == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func IsDeploymentReady(deployment *apps.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.ReadyReplicas >= replicas
+}
+
+func IsStatefulSetReady(sts *apps.StatefulSet) bool {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return sts.Status.ObservedGeneration >= sts.Generation &&
+		sts.Status.ReadyReplicas >= replicas
+}
+
+func IsDeploymentScaledDown(deployment *apps.Deployment) bool {
+	replicas := int32(0)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.Replicas <= replicas
+}
+
+func IsStatefulSetScaledDown(sts *apps.StatefulSet) bool {
+	replicas := int32(0)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return sts.Status.Replicas <= replicas
+}
+
+// IsSkipped checks that object is excluded from startup and shutdown by annotation.
+func IsSkipped(m meta.ObjectMeta) bool {
+	skip, _ := strconv.ParseBool(m.Annotations[_SkipAnnotation])
+	return skip
+}
+
+// GetShutdownReplicas returns replicas count which should remain on shutdown, zero by default.
+func GetShutdownReplicas(m meta.ObjectMeta) int32 {
+	replicas, err := strconv.ParseInt(m.Annotations[_ShutdownReplicasAnnotation], 10, 32)
+	if err != nil || replicas < 0 {
+		return 0
+	}
+	return int32(replicas)
+}
+
+// NewKeptApps collects skipped workloads and workloads with reduced replicas on shutdown.
+func NewKeptApps(
+	deployments []*apps.Deployment,
+	statefulSets []*apps.StatefulSet,
+	daemonSets []*apps.DaemonSet,
+) *KeptApps {
+	kept := &KeptApps{}
+	keep := func(m meta.ObjectMeta, selector *meta.LabelSelector, replicas *int32) {
+		count := int32(1)
+		if replicas != nil {
+			count = *replicas
+		}
+
+		if !IsSkipped(m) {
+			count = util.Min(count, GetShutdownReplicas(m))
+		}
+
+		if count == 0 {
+			return
+		}
+
+		if s, err := meta.LabelSelectorAsSelector(selector); err == nil {
+			kept.Pods += int64(count)
+			kept.selectors = append(kept.selectors, s)
+		}
+	}
+
+	for _, d := range deployments {
+		keep(d.ObjectMeta, d.Spec.Selector, d.Spec.Replicas)
+	}
+
+	for _, s := range statefulSets {
+		keep(s.ObjectMeta, s.Spec.Selector, s.Spec.Replicas)
+	}
+
+	for _, ds := range daemonSets {
+		if IsSkipped(ds.ObjectMeta) {
+			keep(ds.ObjectMeta, ds.Spec.Selector, &ds.Status.DesiredNumberScheduled)
+		}
+	}
+
+	return kept
+}
+
+// Empty checks that there are no kept pods.
+func (k *KeptApps) Empty() bool {
+	return len(k.selectors) == 0
+}
+
+// Has checks that pod belongs to one of kept workloads.
+func (k *KeptApps) Has(pod *core.Pod) bool {
+	for _, s := range k.selectors {
+		if s.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSnapshot captures state of workloads which will be modified on shutdown.
+func NewSnapshot(
+	deployments []*apps.Deployment,
+	statefulSets []*apps.StatefulSet,
+	daemonSets []*apps.DaemonSet,
+	autoscalers []*autoscaling.HorizontalPodAutoscaler,
+	cronJobs []*batch.CronJob,
+) *Snapshot {
+	snapshot := &Snapshot{
+		Deployments:  map[string]int32{},
+		StatefulSets: map[string]int32{},
+		DaemonSets:   map[string]map[string]string{},
+		Autoscalers:  map[string]AutoscalerBounds{},
+		existing:     map[string]bool{},
+	}
+
+	for _, d := range deployments {
+		snapshot.existing["deployment/"+d.Name] = true
+		if !IsSkipped(d.ObjectMeta) && d.Spec.Replicas != nil && *d.Spec.Replicas > GetShutdownReplicas(d.ObjectMeta) {
+			snapshot.Deployments[d.Name] = *d.Spec.Replicas
+		}
+	}
+
+	for _, s := range statefulSets {
+		snapshot.existing["statefulset/"+s.Name] = true
+		if !IsSkipped(s.ObjectMeta) && s.Spec.Replicas != nil && *s.Spec.Replicas > GetShutdownReplicas(s.ObjectMeta) {
+			snapshot.StatefulSets[s.Name] = *s.Spec.Replicas
+		}
+	}
+
+	for _, ds := range daemonSets {
+		snapshot.existing["daemonset/"+ds.Name] = true
+		if !IsSkipped(ds.ObjectMeta) && !IsDaemonSetDisabled(ds) {
+			snapshot.DaemonSets[ds.Name] = ds.Spec.Template.Spec.NodeSelector
+		}
+	}
+
+	for _, hpa := range autoscalers {
+		snapshot.existing["autoscaler/"+hpa.Name] = true
+		if !IsSkipped(hpa.ObjectMeta) {
+			snapshot.Autoscalers[hpa.Name] = AutoscalerBounds{
+				MinReplicas: hpa.Spec.MinReplicas,
+				MaxReplicas: hpa.Spec.MaxReplicas,
+			}
+		}
+	}
+
+	for _, cj := range cronJobs {
+		snapshot.existing["cronjob/"+cj.Name] = true
+		if !IsSkipped(cj.ObjectMeta) && !IsCronJobSuspended(cj) {
+			snapshot.CronJobs = append(snapshot.CronJobs, cj.Name)
+		}
+	}
+
+	return snapshot
+}
+
+// Merge adds workloads from previous snapshot, which are missing in current one (e.g. already stopped),
+// workloads deleted since previous snapshot are dropped.
+func (s *Snapshot) Merge(prev *Snapshot) *Snapshot {
+	if prev == nil {
+		return s
+	}
+	for name, replicas := range prev.Deployments {
+		if _, ok := s.Deployments[name]; !ok && s.existing["deployment/"+name] {
+			s.Deployments[name] = replicas
+		}
+	}
+	for name, replicas := range prev.StatefulSets {
+		if _, ok := s.StatefulSets[name]; !ok && s.existing["statefulset/"+name] {
+			s.StatefulSets[name] = replicas
+		}
+	}
+	for name, selector := range prev.DaemonSets {
+		if _, ok := s.DaemonSets[name]; !ok && s.existing["daemonset/"+name] {
+			s.DaemonSets[name] = selector
+		}
+	}
+	for name, bounds := range prev.Autoscalers {
+		if _, ok := s.Autoscalers[name]; !ok && s.existing["autoscaler/"+name] {
+			s.Autoscalers[name] = bounds
+		}
+	}
+	for _, name := range prev.CronJobs {
+		if !s.HasCronJob(name) && s.existing["cronjob/"+name] {
+			s.CronJobs = append(s.CronJobs, name)
+		}
+	}
+	sort.Strings(s.CronJobs)
+	return s
+}
+
+// HasCronJob checks that cron job was active before shutdown.
+func (s *Snapshot) HasCronJob(name string) bool {
+	if s == nil {
+		return false
+	}
+	for _, cj := range s.CronJobs {
+		if cj == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDeploymentReplicas returns replicas to restore from snapshot, restore annotation used as fallback.
+func (s *Snapshot) GetDeploymentReplicas(deployment *apps.Deployment) (int32, bool) {
+	if s != nil {
+		if replicas, ok := s.Deployments[deployment.Name]; ok {
+			return replicas, true
+		}
+	}
+	return getRestoreReplicas(deployment.ObjectMeta)
+}
+
+// GetStatefulSetReplicas returns replicas to restore from snapshot, restore annotation used as fallback.
+func (s *Snapshot) GetStatefulSetReplicas(sts *apps.StatefulSet) (int32, bool) {
+	if s != nil {
+		if replicas, ok := s.StatefulSets[sts.Name]; ok {
+			return replicas, true
+		}
+	}
+	return getRestoreReplicas(sts.ObjectMeta)
+}
+
+// GetDaemonSetNodeSelector returns node selector to restore from snapshot, restore annotation used as fallback.
+func (s *Snapshot) GetDaemonSetNodeSelector(ds *apps.DaemonSet) (map[string]string, bool) {
+	if !IsDaemonSetDisabled(ds) {
+		return nil, false
+	}
+	if s != nil {
+		if selector, ok := s.DaemonSets[ds.Name]; ok {
+			return selector, true
+		}
+	}
+
+	val, ok := ds.Annotations[_NodeSelectorAnnotation]
+	if !ok {
+		return nil, false
+	}
+
+	var selector map[string]string
+	if err := json.Unmarshal([]byte(val), &selector); err != nil {
+		return nil, false
+	}
+	return selector, true
+}
+
+// GetAutoscalerBounds returns bounds to restore from snapshot, when they differ from current ones.
+func (s *Snapshot) GetAutoscalerBounds(hpa *autoscaling.HorizontalPodAutoscaler) (AutoscalerBounds, bool) {
+	if s == nil {
+		return AutoscalerBounds{}, false
+	}
+	bounds, ok := s.Autoscalers[hpa.Name]
+	if !ok {
+		return AutoscalerBounds{}, false
+	}
+	changed := bounds.MaxReplicas != hpa.Spec.MaxReplicas ||
+		util.Deref(bounds.MinReplicas) != util.Deref(hpa.Spec.MinReplicas)
+	return bounds, changed
+}
+
+// IsCronJobSuspendedByShutdown checks that cron job was suspended on shutdown,
+// it is in snapshot or has restore annotation as fallback.
+func (s *Snapshot) IsCronJobSuspendedByShutdown(cj *batch.CronJob) bool {
+	if !IsCronJobSuspended(cj) {
+		return false
+	}
+	if s.HasCronJob(cj.Name) {
+		return true
+	}
+	_, ok := cj.Annotations[_SuspendAnnotation]
+	return ok
+}
+
+// IsCronJobSuspended checks that cron job doesn't create jobs.
+func IsCronJobSuspended(cj *batch.CronJob) bool {
+	return cj.Spec.Suspend != nil && *cj.Spec.Suspend
+}
+
+// IsDaemonSetDisabled checks that daemonSet pods are disabled by node selector.
+func IsDaemonSetDisabled(ds *apps.DaemonSet) bool {
+	_, disabled := ds.Spec.Template.Spec.NodeSelector[_NodeSelectorDisabledLabel]
+	return disabled
+}
+
+func getRestoreReplicas(m meta.ObjectMeta) (int32, bool) {
+	val, ok := m.Annotations[_ReplicasAnnotation]
+	if !ok {
+		return 0, false
+	}
+	replicas, err := strconv.ParseInt(val, 10, 32)
+	if err != nil || replicas < 0 {
+		return 0, false
+	}
+	return int32(replicas), true
+}
+
+// GroupAppsByOrder groups apps to waves sorted by startup order.
+func GroupAppsByOrder(
+	deployments []*apps.Deployment,
+	statefulSets []*apps.StatefulSet,
+	order StartupOrder,
+) []*AppsWave {
+	waves := make(map[int]*AppsWave)
+	wave := func(order int) *AppsWave {
+		if _, ok := waves[order]; !ok {
+			waves[order] = &AppsWave{Order: order}
+		}
+		return waves[order]
+	}
+
+	for _, d := range deployments {
+		w := wave(order.Get(d.ObjectMeta))
+		w.Deployments = append(w.Deployments, d)
+	}
+
+	for _, s := range statefulSets {
+		w := wave(order.Get(s.ObjectMeta))
+		w.StatefulSets = append(w.StatefulSets, s)
+	}
+
+	orders := util.MapKeys(waves)
+	sort.Ints(orders)
+
+	return util.Project(orders, func(_ int, order int) *AppsWave {
+		return waves[order]
+	})
+}
+
+// NewStartupOrder compiles startup order filters of policy, invalid filter is reported.
+func NewStartupOrder(filters []string) (StartupOrder, error) {
+	order := make(StartupOrder, 0, len(filters))
+	for _, f := range filters {
+		reg, err := regexp2.Compile(f, regexp2.None)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s is not valid regex: %w", f, err)
+		}
+		order = append(order, reg)
+	}
+	return order, nil
+}
+
+// Get returns startup order from object annotation or from position of first matched filter.
+// Objects without annotation and not matched by any filter have zero order.
+func (o StartupOrder) Get(m meta.ObjectMeta) int {
+	if val, ok := m.Annotations[_StartupOrderAnnotation]; ok {
+		if order, err := strconv.Atoi(val); err == nil {
+			return order
+		}
+	}
+
+	for i, reg := range o {
+		if matched, _ := reg.MatchString(m.Name); matched {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// HasDeployment checks that deployment included to wave, nil wave includes all deployments.
+func (w *AppsWave) HasDeployment(name string) bool {
+	if w == nil {
+		return true
+	}
+	for _, d := range w.Deployments {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasStatefulSet checks that statefulSet included to wave, nil wave includes all statefulSets.
+func (w *AppsWave) HasStatefulSet(name string) bool {
+	if w == nil {
+		return true
+	}
+	for _, s := range w.StatefulSets {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetResourceQuotaName returns configured resource quota name or default one.
+func GetResourceQuotaName(spec apis.QuotaSpec) string {
+	if spec.Name == "" {
+		return _ResourceQuotaName
+	}
+	return spec.Name
+}
+
+// IsOwnedByPolicy checks that object created by policy, via owner reference or policy label.
+func IsOwnedByPolicy(m meta.ObjectMeta, policy *apis.StandSchedulePolicy) bool {
+	for _, ref := range m.OwnerReferences {
+		if ref.Kind == "StandSchedulePolicy" && ref.UID == policy.UID {
+			return true
+		}
+	}
+	return m.Labels[_PolicyLabel] == policy.Name
+}
@@ -2,10 +2,16 @@ package executor
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
@@ -59,6 +65,44 @@ func Test_SortNamespaces(t *testing.T) {
 	}
 }
 
+func Test_IsNamespaceMatched(t *testing.T) {
+	cases := []struct {
+		name       string
+		namespace  string
+		filter     string
+		expMatched bool
+	}{
+		{
+			name:       "matched",
+			namespace:  "stand-1",
+			filter:     "^stand-.*|^other$",
+			expMatched: true,
+		},
+		{
+			name:       "not matched",
+			namespace:  "kube-system",
+			filter:     "^stand-.*|^other$",
+			expMatched: false,
+		},
+		{
+			name:       "empty filter",
+			namespace:  "stand-1",
+			filter:     "",
+			expMatched: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := IsNamespaceMatched(tc.namespace, tc.filter)
+
+			assert.Equal(t, tc.expMatched, actual)
+		})
+	}
+}
+
 func Test_FilterAndMergeAzureResources(t *testing.T) {
 	cases := []struct {
 		name         string
@@ -110,6 +154,69 @@ func Test_FilterAndMergeAzureResources(t *testing.T) {
 				"Microsoft.DBforMySQL/servers/test/test-mysql-clc-suffix",
 			},
 		},
+		{
+			name: "node pools by pool name",
+			resources: []*azure.Resource{
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.ContainerService/managedClusters/aks/agentPools/agents"),
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.ContainerService/managedClusters/aks/agentPools/workers"),
+			},
+			filter: apis.AzureResource{
+				Type:               apis.AzureResourceAKSNodePool,
+				ResourceGroupName:  "test",
+				ResourceNameFilter: "^agents$",
+				Priority:           1,
+			},
+			expResources: []string{
+				"Microsoft.ContainerService/managedClusters/agentPools/test/agents",
+			},
+		},
+		{
+			name: "tags across resource groups",
+			resources: []*azure.Resource{
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test-1/providers/Microsoft.Compute/virtualMachines/vm-a").
+					WithTags(map[string]*string{"stand": util.Pointer("dev"), "schedule": util.Pointer("")}),
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test-2/providers/Microsoft.Compute/virtualMachines/vm-b").
+					WithTags(map[string]*string{"stand": util.Pointer("dev")}),
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test-2/providers/Microsoft.Compute/virtualMachines/vm-c").
+					WithTags(map[string]*string{"stand": util.Pointer("qa"), "schedule": util.Pointer("")}),
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test-3/providers/Microsoft.Compute/virtualMachines/vm-d"),
+			},
+			filter: apis.AzureResource{
+				Type: apis.AzureResourceVirtualMachine,
+				TagSelector: &apis.AzureTagSelector{
+					MatchTags: map[string]string{"stand": "dev"},
+					MatchExpressions: []apis.AzureTagRequirement{
+						{Key: "schedule", Operator: apis.AzureTagOpExists},
+					},
+				},
+				Priority: 1,
+			},
+			expResources: []string{
+				"Microsoft.Compute/virtualMachines/test-1/vm-a",
+			},
+		},
+		{
+			name: "tags with name filter",
+			resources: []*azure.Resource{
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Compute/virtualMachines/vm-a").
+					WithTags(map[string]*string{"stand": util.Pointer("dev")}),
+				azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Compute/virtualMachines/db-a").
+					WithTags(map[string]*string{"stand": util.Pointer("dev")}),
+			},
+			filter: apis.AzureResource{
+				Type:               apis.AzureResourceVirtualMachine,
+				ResourceNameFilter: "^vm-",
+				TagSelector: &apis.AzureTagSelector{
+					MatchExpressions: []apis.AzureTagRequirement{
+						{Key: "stand", Operator: apis.AzureTagOpIn, Values: []string{"dev", "qa"}},
+					},
+				},
+				Priority: 1,
+			},
+			expResources: []string{
+				"Microsoft.Compute/virtualMachines/test/vm-a",
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -127,3 +234,777 @@ func Test_FilterAndMergeAzureResources(t *testing.T) {
 		})
 	}
 }
+
+func Test_FilterAndMergeOverlappingAzureResources(t *testing.T) {
+	newList := func() []*azure.Resource {
+		return []*azure.Resource{
+			azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Compute/virtualMachines/vm-a").
+				WithTags(map[string]*string{"stand": util.Pointer("dev")}),
+			azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Compute/virtualMachines/vm-b"),
+		}
+	}
+	byName := apis.AzureResource{
+		Type:               apis.AzureResourceVirtualMachine,
+		ResourceGroupName:  "test",
+		ResourceNameFilter: "^vm-",
+	}
+	byTags := apis.AzureResource{
+		Type:        apis.AzureResourceVirtualMachine,
+		TagSelector: &apis.AzureTagSelector{MatchTags: map[string]string{"stand": "dev"}},
+	}
+
+	cases := []struct {
+		name         string
+		filters      []apis.AzureResource
+		priorities   []int64
+		expResources map[int64][]string
+	}{
+		{
+			name:       "same priority",
+			filters:    []apis.AzureResource{byName, byTags},
+			priorities: []int64{1, 1},
+			expResources: map[int64][]string{
+				1: {"Microsoft.Compute/virtualMachines/test/vm-a", "Microsoft.Compute/virtualMachines/test/vm-b"},
+			},
+		},
+		{
+			name:       "first priority kept",
+			filters:    []apis.AzureResource{byTags, byName},
+			priorities: []int64{1, 2},
+			expResources: map[int64][]string{
+				1: {"Microsoft.Compute/virtualMachines/test/vm-a"},
+				2: {"Microsoft.Compute/virtualMachines/test/vm-b"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			result := make(map[int64][]*azure.Resource)
+			for i, filter := range tc.filters {
+				filter.Priority = tc.priorities[i]
+				// every filter lists resources by its own request
+				FilterAndMergeAzureResources(result, newList(), filter)
+			}
+
+			actual := map[int64][]string{}
+			for priority, group := range result {
+				actual[priority] = util.Project(group, func(_ int, r *azure.Resource) string {
+					return r.String()
+				})
+			}
+			assert.Equal(t, tc.expResources, actual)
+		})
+	}
+}
+
+func Test_GetAzurePriorities(t *testing.T) {
+	cases := []struct {
+		name          string
+		priorities    []int64
+		expPriorities []int64
+	}{
+		{name: "no entries"},
+		{name: "distinct", priorities: []int64{0, 1, 2}, expPriorities: []int64{0, 1, 2}},
+		{name: "shared priority", priorities: []int64{2, 1, 1, 0, 0}, expPriorities: []int64{2, 1, 0}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			filters := util.Project(tc.priorities, func(_ int, priority int64) apis.AzureResource {
+				return apis.AzureResource{Priority: priority}
+			})
+
+			assert.Equal(t, tc.expPriorities, GetAzurePriorities(filters))
+		})
+	}
+}
+
+func Test_GetUnselectedAzureResources(t *testing.T) {
+	vm := azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.Compute/virtualMachines/vm")
+	db := azure.NewResource("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/test/providers/Microsoft.DBforMySQL/servers/db")
+
+	cases := []struct {
+		name          string
+		statuses      map[string]apis.AzureResourceStatus
+		selected      map[int64][]*azure.Resource
+		expUnselected []string
+	}{
+		{
+			name:     "no statuses",
+			selected: map[int64][]*azure.Resource{1: {vm}},
+		},
+		{
+			name: "all selected",
+			statuses: map[string]apis.AzureResourceStatus{
+				vm.GetID(): {PowerState: "Running"},
+				db.GetID(): {PowerState: "Running"},
+			},
+			selected: map[int64][]*azure.Resource{1: {vm}, 2: {db}},
+		},
+		{
+			name: "resource no longer selected",
+			statuses: map[string]apis.AzureResourceStatus{
+				vm.GetID(): {PowerState: "Running"},
+				db.GetID(): {PowerState: "Stopped"},
+			},
+			selected:      map[int64][]*azure.Resource{1: {vm}},
+			expUnselected: []string{db.GetID()},
+		},
+		{
+			name: "no resources selected",
+			statuses: map[string]apis.AzureResourceStatus{
+				vm.GetID(): {PowerState: "Running"},
+				db.GetID(): {PowerState: "Stopped"},
+			},
+			expUnselected: []string{vm.GetID(), db.GetID()},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := GetUnselectedAzureResources(tc.statuses, tc.selected)
+
+			assert.Equal(t, tc.expUnselected, actual)
+		})
+	}
+}
+
+func Test_IsPodTerminated(t *testing.T) {
+	cases := []struct {
+		name          string
+		pod           *core.Pod
+		expTerminated bool
+	}{
+		{
+			name:          "no containers",
+			pod:           &core.Pod{},
+			expTerminated: true,
+		},
+		{
+			name: "all containers terminated",
+			pod: &core.Pod{
+				Status: core.PodStatus{
+					ContainerStatuses: []core.ContainerStatus{
+						{State: core.ContainerState{Terminated: &core.ContainerStateTerminated{}}},
+						{State: core.ContainerState{Terminated: &core.ContainerStateTerminated{}}},
+					},
+				},
+			},
+			expTerminated: true,
+		},
+		{
+			name: "some containers running",
+			pod: &core.Pod{
+				Status: core.PodStatus{
+					ContainerStatuses: []core.ContainerStatus{
+						{State: core.ContainerState{Terminated: &core.ContainerStateTerminated{}}},
+						{State: core.ContainerState{Running: &core.ContainerStateRunning{}}},
+					},
+				},
+			},
+			expTerminated: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expTerminated, IsPodTerminated(tc.pod))
+		})
+	}
+}
+
+func Test_IsDeploymentReady(t *testing.T) {
+	cases := []struct {
+		name       string
+		deployment *apps.Deployment
+		expReady   bool
+	}{
+		{
+			name: "ready",
+			deployment: &apps.Deployment{
+				ObjectMeta: meta.ObjectMeta{Generation: 2},
+				Spec:       apps.DeploymentSpec{Replicas: util.Pointer(int32(3))},
+				Status:     apps.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 3},
+			},
+			expReady: true,
+		},
+		{
+			name: "not all replicas ready",
+			deployment: &apps.Deployment{
+				ObjectMeta: meta.ObjectMeta{Generation: 2},
+				Spec:       apps.DeploymentSpec{Replicas: util.Pointer(int32(3))},
+				Status:     apps.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 1},
+			},
+			expReady: false,
+		},
+		{
+			name: "generation not observed",
+			deployment: &apps.Deployment{
+				ObjectMeta: meta.ObjectMeta{Generation: 3},
+				Spec:       apps.DeploymentSpec{Replicas: util.Pointer(int32(3))},
+				Status:     apps.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 3},
+			},
+			expReady: false,
+		},
+		{
+			name: "scaled to zero",
+			deployment: &apps.Deployment{
+				Spec: apps.DeploymentSpec{Replicas: util.Pointer(int32(0))},
+			},
+			expReady: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expReady, IsDeploymentReady(tc.deployment))
+		})
+	}
+}
+
+func Test_IsStatefulSetReady(t *testing.T) {
+	cases := []struct {
+		name     string
+		sts      *apps.StatefulSet
+		expReady bool
+	}{
+		{
+			name: "ready",
+			sts: &apps.StatefulSet{
+				ObjectMeta: meta.ObjectMeta{Generation: 1},
+				Spec:       apps.StatefulSetSpec{Replicas: util.Pointer(int32(2))},
+				Status:     apps.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2},
+			},
+			expReady: true,
+		},
+		{
+			name: "default replicas not ready",
+			sts: &apps.StatefulSet{
+				ObjectMeta: meta.ObjectMeta{Generation: 1},
+				Status:     apps.StatefulSetStatus{ObservedGeneration: 1},
+			},
+			expReady: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expReady, IsStatefulSetReady(tc.sts))
+		})
+	}
+}
+
+func Test_GroupAppsByOrder(t *testing.T) {
+	deployments := []*apps.Deployment{
+		{ObjectMeta: meta.ObjectMeta{Name: "worker-orders"}},
+		{ObjectMeta: meta.ObjectMeta{Name: "api"}},
+		{ObjectMeta: meta.ObjectMeta{Name: "mock-smtp"}},
+		{ObjectMeta: meta.ObjectMeta{
+			Name:        "config-server",
+			Annotations: map[string]string{apis.AnnotationPrefix + "/startup-order": "1"},
+		}},
+	}
+	statefulSets := []*apps.StatefulSet{
+		{ObjectMeta: meta.ObjectMeta{Name: "redis"}},
+	}
+
+	order, err := NewStartupOrder([]string{"^config-server$", "^api$", "^worker-.*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waves := GroupAppsByOrder(deployments, statefulSets, order)
+	actual := util.Project(waves, func(_ int, w *AppsWave) []string {
+		return append(
+			util.Project(w.StatefulSets, func(_ int, s *apps.StatefulSet) string { return "statefulset/" + s.Name }),
+			util.Project(w.Deployments, func(_ int, d *apps.Deployment) string { return "deployment/" + d.Name })...,
+		)
+	})
+
+	assert.Exactly(t, [][]string{
+		{"statefulset/redis", "deployment/mock-smtp"},
+		{"deployment/config-server"},
+		{"deployment/api"},
+		{"deployment/worker-orders"},
+	}, actual)
+}
+
+func Test_StartupOrderGet(t *testing.T) {
+	cases := []struct {
+		name     string
+		object   meta.ObjectMeta
+		filters  []string
+		expOrder int
+	}{
+		{
+			name:     "not matched",
+			object:   meta.ObjectMeta{Name: "api"},
+			filters:  []string{"^worker-.*"},
+			expOrder: 0,
+		},
+		{
+			name:     "matched by filter",
+			object:   meta.ObjectMeta{Name: "worker-orders"},
+			filters:  []string{"^api$", "^worker-.*"},
+			expOrder: 2,
+		},
+		{
+			name: "annotation takes precedence",
+			object: meta.ObjectMeta{
+				Name:        "worker-orders",
+				Annotations: map[string]string{apis.AnnotationPrefix + "/startup-order": "10"},
+			},
+			filters:  []string{"^api$", "^worker-.*"},
+			expOrder: 10,
+		},
+		{
+			name: "invalid annotation ignored",
+			object: meta.ObjectMeta{
+				Name:        "api",
+				Annotations: map[string]string{apis.AnnotationPrefix + "/startup-order": "first"},
+			},
+			filters:  []string{"^api$"},
+			expOrder: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := NewStartupOrder(tc.filters)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expOrder, order.Get(tc.object))
+		})
+	}
+}
+
+func Test_NewStartupOrder(t *testing.T) {
+	cases := []struct {
+		name     string
+		filters  []string
+		expError bool
+	}{
+		{name: "no filters"},
+		{name: "valid filters", filters: []string{"^api$", "^worker-.*"}},
+		{name: "invalid filter", filters: []string{"^api$", "^worker-(.*"}, expError: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewStartupOrder(tc.filters)
+
+			assert.Equal(t, tc.expError, err != nil)
+		})
+	}
+}
+
+func Test_GetShutdownReplicas(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expReplicas int32
+	}{
+		{
+			name:        "no annotation",
+			annotations: nil,
+			expReplicas: 0,
+		},
+		{
+			name:        "reduced replicas",
+			annotations: map[string]string{apis.AnnotationPrefix + "/shutdown-replicas": "1"},
+			expReplicas: 1,
+		},
+		{
+			name:        "invalid replicas",
+			annotations: map[string]string{apis.AnnotationPrefix + "/shutdown-replicas": "-1"},
+			expReplicas: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := GetShutdownReplicas(meta.ObjectMeta{Annotations: tc.annotations})
+
+			assert.Equal(t, tc.expReplicas, actual)
+		})
+	}
+}
+
+func Test_NewKeptApps(t *testing.T) {
+	selector := func(app string) *meta.LabelSelector {
+		return &meta.LabelSelector{MatchLabels: map[string]string{"app": app}}
+	}
+	pod := func(app string) *core.Pod {
+		return &core.Pod{ObjectMeta: meta.ObjectMeta{Labels: map[string]string{"app": app}}}
+	}
+
+	kept := NewKeptApps(
+		[]*apps.Deployment{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "api"},
+				Spec:       apps.DeploymentSpec{Replicas: util.Pointer(int32(3)), Selector: selector("api")},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "mock-smtp",
+					Annotations: map[string]string{apis.AnnotationPrefix + "/skip": "true"},
+				},
+				Spec: apps.DeploymentSpec{Replicas: util.Pointer(int32(2)), Selector: selector("mock-smtp")},
+			},
+		},
+		[]*apps.StatefulSet{
+			{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "redis",
+					Annotations: map[string]string{apis.AnnotationPrefix + "/shutdown-replicas": "1"},
+				},
+				Spec: apps.StatefulSetSpec{Replicas: util.Pointer(int32(3)), Selector: selector("redis")},
+			},
+		},
+		[]*apps.DaemonSet{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "fluentbit"},
+				Spec:       apps.DaemonSetSpec{Selector: selector("fluentbit")},
+				Status:     apps.DaemonSetStatus{DesiredNumberScheduled: 3},
+			},
+		},
+	)
+
+	assert.Equal(t, int64(3), kept.Pods)
+	assert.False(t, kept.Empty())
+	assert.False(t, kept.Has(pod("api")))
+	assert.True(t, kept.Has(pod("mock-smtp")))
+	assert.True(t, kept.Has(pod("redis")))
+	assert.False(t, kept.Has(pod("fluentbit")))
+}
+
+func Test_IsOwnedByPolicy(t *testing.T) {
+	policy := &apis.StandSchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{Name: "policy", UID: types.UID("uid")},
+	}
+	cases := []struct {
+		name     string
+		meta     meta.ObjectMeta
+		expOwned bool
+	}{
+		{
+			name:     "foreign object",
+			meta:     meta.ObjectMeta{},
+			expOwned: false,
+		},
+		{
+			name: "owner reference",
+			meta: meta.ObjectMeta{
+				OwnerReferences: []meta.OwnerReference{{Kind: "StandSchedulePolicy", UID: types.UID("uid")}},
+			},
+			expOwned: true,
+		},
+		{
+			name: "owner reference of another policy",
+			meta: meta.ObjectMeta{
+				OwnerReferences: []meta.OwnerReference{{Kind: "StandSchedulePolicy", UID: types.UID("other")}},
+			},
+			expOwned: false,
+		},
+		{
+			name:     "policy label",
+			meta:     meta.ObjectMeta{Labels: map[string]string{apis.AnnotationPrefix + "/policy": "policy"}},
+			expOwned: true,
+		},
+		{
+			name:     "policy label of another policy",
+			meta:     meta.ObjectMeta{Labels: map[string]string{apis.AnnotationPrefix + "/policy": "other"}},
+			expOwned: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := IsOwnedByPolicy(tc.meta, policy)
+
+			assert.Equal(t, tc.expOwned, actual)
+		})
+	}
+}
+
+func Test_NewSnapshot(t *testing.T) {
+	prev := &Snapshot{
+		Deployments:  map[string]int32{"api": 5, "worker": 2, "removed": 1},
+		StatefulSets: map[string]int32{"redis": 3},
+		Autoscalers:  map[string]AutoscalerBounds{"removed": {MaxReplicas: 3}},
+		CronJobs:     []string{"cleanup", "removed"},
+	}
+
+	snapshot := NewSnapshot(
+		[]*apps.Deployment{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "api"},
+				Spec:       apps.DeploymentSpec{Replicas: util.Pointer(int32(3))},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "worker"},
+				Spec:       apps.DeploymentSpec{Replicas: util.Pointer(int32(0))},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "mock-smtp",
+					Annotations: map[string]string{apis.AnnotationPrefix + "/skip": "true"},
+				},
+				Spec: apps.DeploymentSpec{Replicas: util.Pointer(int32(2))},
+			},
+		},
+		[]*apps.StatefulSet{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "redis"},
+				Spec:       apps.StatefulSetSpec{Replicas: util.Pointer(int32(0))},
+			},
+		},
+		[]*apps.DaemonSet{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "fluentbit"},
+				Spec: apps.DaemonSetSpec{Template: core.PodTemplateSpec{Spec: core.PodSpec{
+					NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+				}}},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "node-exporter"},
+				Spec: apps.DaemonSetSpec{Template: core.PodTemplateSpec{Spec: core.PodSpec{
+					NodeSelector: map[string]string{apis.AnnotationPrefix + "/disabled": "true"},
+				}}},
+			},
+		},
+		[]*autoscaling.HorizontalPodAutoscaler{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "api"},
+				Spec:       autoscaling.HorizontalPodAutoscalerSpec{MinReplicas: util.Pointer(int32(2)), MaxReplicas: 10},
+			},
+		},
+		[]*batch.CronJob{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "cleanup"},
+				Spec:       batch.CronJobSpec{Suspend: util.Pointer(true)},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "report"},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "disabled"},
+				Spec:       batch.CronJobSpec{Suspend: util.Pointer(true)},
+			},
+		},
+	).Merge(prev)
+
+	// entries of deleted workloads are dropped from previous snapshot
+	assert.Equal(t, map[string]int32{"api": 3, "worker": 2}, snapshot.Deployments)
+	assert.Equal(t, map[string]int32{"redis": 3}, snapshot.StatefulSets)
+	assert.Equal(t, map[string]map[string]string{"fluentbit": {"kubernetes.io/os": "linux"}}, snapshot.DaemonSets)
+	assert.Equal(t, map[string]AutoscalerBounds{"api": {MinReplicas: util.Pointer(int32(2)), MaxReplicas: 10}}, snapshot.Autoscalers)
+	assert.Equal(t, []string{"cleanup", "report"}, snapshot.CronJobs)
+}
+
+func Test_SnapshotGetAutoscalerBounds(t *testing.T) {
+	snapshot := &Snapshot{
+		Autoscalers: map[string]AutoscalerBounds{"api": {MinReplicas: util.Pointer(int32(2)), MaxReplicas: 10}},
+	}
+
+	cases := []struct {
+		name       string
+		hpa        *autoscaling.HorizontalPodAutoscaler
+		expChanged bool
+	}{
+		{
+			name: "not changed",
+			hpa: &autoscaling.HorizontalPodAutoscaler{
+				ObjectMeta: meta.ObjectMeta{Name: "api"},
+				Spec:       autoscaling.HorizontalPodAutoscalerSpec{MinReplicas: util.Pointer(int32(2)), MaxReplicas: 10},
+			},
+		},
+		{
+			name: "changed",
+			hpa: &autoscaling.HorizontalPodAutoscaler{
+				ObjectMeta: meta.ObjectMeta{Name: "api"},
+				Spec:       autoscaling.HorizontalPodAutoscalerSpec{MinReplicas: util.Pointer(int32(1)), MaxReplicas: 1},
+			},
+			expChanged: true,
+		},
+		{
+			name: "not in snapshot",
+			hpa: &autoscaling.HorizontalPodAutoscaler{
+				ObjectMeta: meta.ObjectMeta{Name: "worker"},
+				Spec:       autoscaling.HorizontalPodAutoscalerSpec{MaxReplicas: 1},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, changed := snapshot.GetAutoscalerBounds(tc.hpa)
+
+			assert.Equal(t, tc.expChanged, changed)
+		})
+	}
+}
+
+func Test_SnapshotIsCronJobSuspendedByShutdown(t *testing.T) {
+	cases := []struct {
+		name         string
+		snapshot     *Snapshot
+		cronJob      *batch.CronJob
+		expSuspended bool
+	}{
+		{
+			name:     "active",
+			snapshot: &Snapshot{CronJobs: []string{"report"}},
+			cronJob:  &batch.CronJob{ObjectMeta: meta.ObjectMeta{Name: "report"}},
+		},
+		{
+			name:         "suspended by shutdown",
+			snapshot:     &Snapshot{CronJobs: []string{"report"}},
+			cronJob:      &batch.CronJob{ObjectMeta: meta.ObjectMeta{Name: "report"}, Spec: batch.CronJobSpec{Suspend: util.Pointer(true)}},
+			expSuspended: true,
+		},
+		{
+			name: "suspended by shutdown without snapshot",
+			cronJob: &batch.CronJob{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "report",
+					Annotations: map[string]string{apis.AnnotationPrefix + "/restore-suspend": "false"},
+				},
+				Spec: batch.CronJobSpec{Suspend: util.Pointer(true)},
+			},
+			expSuspended: true,
+		},
+		{
+			name:     "suspended before shutdown",
+			snapshot: &Snapshot{CronJobs: []string{"report"}},
+			cronJob:  &batch.CronJob{ObjectMeta: meta.ObjectMeta{Name: "cleanup"}, Spec: batch.CronJobSpec{Suspend: util.Pointer(true)}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expSuspended, tc.snapshot.IsCronJobSuspendedByShutdown(tc.cronJob))
+		})
+	}
+}
+
+func Test_SnapshotGetDeploymentReplicas(t *testing.T) {
+	cases := []struct {
+		name        string
+		snapshot    *Snapshot
+		annotations map[string]string
+		expReplicas int32
+		expOk       bool
+	}{
+		{
+			name:        "no snapshot and annotation",
+			snapshot:    nil,
+			annotations: nil,
+			expReplicas: 0,
+			expOk:       false,
+		},
+		{
+			name:        "annotation fallback",
+			snapshot:    nil,
+			annotations: map[string]string{apis.AnnotationPrefix + "/restore-replicas": "2"},
+			expReplicas: 2,
+			expOk:       true,
+		},
+		{
+			name:        "snapshot overrides annotation",
+			snapshot:    &Snapshot{Deployments: map[string]int32{"api": 4}},
+			annotations: map[string]string{apis.AnnotationPrefix + "/restore-replicas": "2"},
+			expReplicas: 4,
+			expOk:       true,
+		},
+		{
+			name:        "snapshot without deployment",
+			snapshot:    &Snapshot{Deployments: map[string]int32{"worker": 4}},
+			annotations: nil,
+			expReplicas: 0,
+			expOk:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			replicas, ok := tc.snapshot.GetDeploymentReplicas(&apps.Deployment{
+				ObjectMeta: meta.ObjectMeta{Name: "api", Annotations: tc.annotations},
+			})
+
+			assert.Equal(t, tc.expReplicas, replicas)
+			assert.Equal(t, tc.expOk, ok)
+		})
+	}
+}
+
+func Test_NewCheckpoint(t *testing.T) {
+	fireAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		prev     *apis.ExecutionCheckpoint
+		expSteps []string
+	}{
+		{
+			name: "no previous checkpoint",
+		},
+		{
+			name:     "same schedule resumed",
+			prev:     &apis.ExecutionCheckpoint{Type: apis.StatusShutdown, FireTime: meta.NewTime(fireAt), CompletedSteps: []string{"namespace/first"}},
+			expSteps: []string{"namespace/first"},
+		},
+		{
+			name: "other fire time not resumed",
+			prev: &apis.ExecutionCheckpoint{Type: apis.StatusShutdown, FireTime: meta.NewTime(fireAt.Add(-time.Hour)), CompletedSteps: []string{"namespace/first"}},
+		},
+		{
+			name: "other schedule type not resumed",
+			prev: &apis.ExecutionCheckpoint{Type: apis.StatusStartup, FireTime: meta.NewTime(fireAt), CompletedSteps: []string{"namespace/first"}},
+		},
+		{
+			name: "cancelled not resumed",
+			prev: &apis.ExecutionCheckpoint{Type: apis.StatusShutdown, FireTime: meta.NewTime(fireAt), CompletedSteps: []string{"namespace/first"}, Cancelled: true},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ex := &Executor{logger: zap.NewNop()}
+			policy := &apis.StandSchedulePolicy{Status: apis.StandSchedulePolicyStatus{Checkpoint: tc.prev}}
+
+			cp := ex.newCheckpoint(policy, apis.StatusShutdown, fireAt)
+
+			assert.Equal(t, tc.expSteps, cp.state.CompletedSteps)
+			assert.False(t, cp.state.Cancelled)
+		})
+	}
+}
@@ -2,8 +2,11 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
 
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
@@ -11,39 +14,208 @@ import (
 	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
 )
 
-func (ex *Executor) executeShutdownAzure(ctx context.Context, filters apis.AzureResourceList) error {
-	resources, err := ex.fetchAzureResources(ctx, filters, false)
+func (ex *Executor) executeShutdownAzure(
+	ctx context.Context,
+	policy *apis.StandSchedulePolicy,
+	filters apis.AzureResourceList,
+	cp *checkpoint,
+) error {
+	if len(filters) == 0 {
+		return ex.pruneResourceStatuses(ctx, policy, nil, cp)
+	}
+	if !ex.IsAzureEnabled() {
+		return ex.azureDisabled(filters)
+	}
+
+	clients, err := ex.newAzureClients(ctx, policy)
+	if err != nil {
+		ex.logger.Warn("Failed to create azure clients", zap.Error(err))
+		return err
+	}
+
+	resources, err := ex.fetchAzureResources(ctx, clients, filters, false)
 	if err != nil {
 		ex.logger.Warn("Failed to list target azure resources", zap.Error(err))
 		return err
 	}
+	if err := ex.pruneResourceStatuses(ctx, policy, resources, cp); err != nil {
+		return err
+	}
 
 	ex.logger.Debug("Shutdown azure resources")
-	return util.ForEachE(filters, func(_ int, filter apis.AzureResource) error {
-		return util.ForEachParallelE(resources[filter.Priority], func(_ int, resource *azure.Resource) error {
-			ex.logger.Debug("Shutdown azure resource", zap.Stringer("resource", resource))
-			return ex.azure.Shutdown(ctx, resource, false)
+	// filters are sorted by fetch in order of execution, entries of the same priority share single group
+	return util.ForEachE(GetAzurePriorities(filters), func(_ int, priority int64) error {
+		step := azureStep(priority)
+		if cp.Done(step) {
+			return nil
+		}
+
+		group := resources[priority]
+		statuses := make([]apis.AzureResourceStatus, len(group))
+		err := util.ForEachParallelE(group, func(i int, resource *azure.Resource) error {
+			az, err := clients.get(resource.GetSubscriptionId())
+			if err != nil {
+				return err
+			}
+			// scale is stored before shutdown, so it is not lost when shutdown fails after resource is scaled down
+			if err := ex.captureResourceScale(ctx, az, policy, resource); err != nil {
+				err = degradeOnOpenCircuit(err)
+				statuses[i] = apis.AzureResourceStatus{
+					Operation: apis.StatusShutdown,
+					Outcome:   apis.AzureOutcomeFailed,
+					Message:   err.Error(),
+				}
+				return err
+			}
+			statuses[i], err = ex.operateAzureResource(ctx, az, resource, apis.StatusShutdown, resource.GetWait())
+			return err
 		})
+		err = multierr.Append(err, ex.saveResourceStatuses(ctx, policy, group, statuses))
+		return cp.Complete(ctx, step, err)
 	})
 }
 
-func (ex *Executor) executeStartupAzure(ctx context.Context, filters apis.AzureResourceList) error {
-	resources, err := ex.fetchAzureResources(ctx, filters, true)
+func (ex *Executor) executeStartupAzure(
+	ctx context.Context,
+	policy *apis.StandSchedulePolicy,
+	filters apis.AzureResourceList,
+	cp *checkpoint,
+) error {
+	if len(filters) == 0 {
+		return ex.pruneResourceStatuses(ctx, policy, nil, cp)
+	}
+	if !ex.IsAzureEnabled() {
+		return ex.azureDisabled(filters)
+	}
+
+	clients, err := ex.newAzureClients(ctx, policy)
+	if err != nil {
+		ex.logger.Warn("Failed to create azure clients", zap.Error(err))
+		return err
+	}
+
+	resources, err := ex.fetchAzureResources(ctx, clients, filters, true)
 	if err != nil {
 		ex.logger.Warn("Failed to list target azure resources", zap.Error(err))
 		return err
 	}
+	if err := ex.pruneResourceStatuses(ctx, policy, resources, cp); err != nil {
+		return err
+	}
 
 	ex.logger.Debug("Startup azure resources")
-	return util.ForEachE(filters, func(_ int, filter apis.AzureResource) error {
-		return util.ForEachParallelE(resources[filter.Priority], func(_ int, resource *azure.Resource) error {
-			ex.logger.Debug("Startup azure resource", zap.Stringer("resource", resource))
-			return ex.azure.Startup(ctx, resource, true)
+	// filters are sorted by fetch in order of execution, entries of the same priority share single group
+	return util.ForEachE(GetAzurePriorities(filters), func(_ int, priority int64) error {
+		step := azureStep(priority)
+		if cp.Done(step) {
+			return nil
+		}
+
+		group := resources[priority]
+		statuses := make([]apis.AzureResourceStatus, len(group))
+		err := util.ForEachParallelE(group, func(i int, resource *azure.Resource) error {
+			if scale, ok := policy.Status.NodePools[resource.GetID()]; ok {
+				resource.SetScale(&scale)
+			}
+			if replicas, ok := policy.Status.ContainerApps[resource.GetID()]; ok {
+				resource.SetReplicas(&replicas)
+			}
+			az, err := clients.get(resource.GetSubscriptionId())
+			if err != nil {
+				return err
+			}
+			statuses[i], err = ex.operateAzureResource(ctx, az, resource, apis.StatusStartup, true)
+			if err != nil {
+				return err
+			}
+			return ex.deleteResourceScale(ctx, policy, resource)
 		})
+		err = multierr.Append(err, ex.saveResourceStatuses(ctx, policy, group, statuses))
+		return cp.Complete(ctx, step, err)
 	})
 }
 
-func (ex *Executor) fetchAzureResources(ctx context.Context, filters apis.AzureResourceList, reverse bool) (map[int64][]*azure.Resource, error) {
+// operateAzureResource performs startup or shutdown of resource, unless it is already in target state.
+// Operation is awaited up to wait timeout of resource, timed out operation degrades execution.
+func (ex *Executor) operateAzureResource(
+	ctx context.Context,
+	az azure.Interface,
+	resource *azure.Resource,
+	scheduleType apis.ConditionScheduleType,
+	wait bool,
+) (apis.AzureResourceStatus, error) {
+	status := apis.AzureResourceStatus{Operation: scheduleType}
+
+	state := ex.getPowerState(ctx, az, resource)
+	if scheduleType == apis.StatusShutdown && state.IsStopped() || scheduleType == apis.StatusStartup && state.IsRunning() {
+		ex.logger.Debug("Skip azure resource already in target state",
+			zap.Stringer("resource", resource),
+			zap.String("schedule_type", string(scheduleType)),
+			zap.String("state", string(state)))
+		status.PowerState = string(state)
+		status.Outcome = apis.AzureOutcomeSkipped
+		return status, nil
+	}
+
+	timeout := resource.GetWaitTimeout()
+	ex.logger.Debug("Execute azure resource operation",
+		zap.Stringer("resource", resource),
+		zap.String("schedule_type", string(scheduleType)),
+		zap.Bool("wait", wait),
+		zap.Stringer("timeout", timeout))
+
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var err error
+	if scheduleType == apis.StatusShutdown {
+		err = az.Shutdown(opCtx, resource, wait)
+	} else {
+		err = az.Startup(opCtx, resource, wait)
+	}
+	status.PowerState = string(ex.getPowerState(ctx, az, resource))
+
+	switch {
+	case err == nil && wait:
+		status.Outcome = apis.AzureOutcomeCompleted
+	case err == nil:
+		status.Outcome = apis.AzureOutcomeRequested
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		err = util.NewDegradedError(fmt.Errorf("%s of azure resource %s not completed after %s", scheduleType, resource, timeout))
+		status.Outcome = apis.AzureOutcomeTimedOut
+		status.Message = err.Error()
+	default:
+		err = degradeOnOpenCircuit(err)
+		status.Outcome = apis.AzureOutcomeFailed
+		status.Message = err.Error()
+	}
+	return status, err
+}
+
+// getPowerState returns power state of resource, unknown state doesn't prevent operation on resource.
+func (ex *Executor) getPowerState(ctx context.Context, az azure.Interface, resource *azure.Resource) azure.PowerState {
+	state, err := az.GetState(ctx, resource)
+	if err != nil {
+		ex.logger.Warn("Failed to get power state of azure resource",
+			zap.Stringer("resource", resource),
+			zap.Error(err))
+		return azure.PowerStateUnknown
+	}
+	return state
+}
+
+// azureDisabled skips azure resources of policy, execution is degraded as they are left as is.
+func (ex *Executor) azureDisabled(filters apis.AzureResourceList) error {
+	ex.logger.Warn("Skip azure resources of policy, because azure provider is disabled")
+	return util.NewDegradedError(fmt.Errorf("%w, %d azure resources entries skipped", azure.ErrDisabled, len(filters)))
+}
+
+func (ex *Executor) fetchAzureResources(
+	ctx context.Context,
+	clients *azureClients,
+	filters apis.AzureResourceList,
+	reverse bool,
+) (map[int64][]*azure.Resource, error) {
 	result := make(map[int64][]*azure.Resource)
 	sortFilters := sort.Interface(filters)
 	if reverse {
@@ -57,11 +229,16 @@ func (ex *Executor) fetchAzureResources(ctx context.Context, filters apis.AzureR
 			return err
 		}
 
-		list, err := ex.azure.List(ctx, azureType, filter.ResourceGroupName)
+		az, err := clients.get(filter.SubscriptionId)
 		if err != nil {
 			return err
 		}
 
+		list, err := az.List(ctx, azureType, filter.ResourceGroupName)
+		if err != nil {
+			return degradeOnOpenCircuit(err)
+		}
+
 		names := util.Project(list, func(_ int, r *azure.Resource) string {
 			return r.String()
 		})
@@ -71,3 +248,124 @@ func (ex *Executor) fetchAzureResources(ctx context.Context, filters apis.AzureR
 		return nil
 	})
 }
+
+// degradeOnOpenCircuit marks as degraded errors of subscription, which failed repeatedly, so execution is not
+// retried from scratch while azure is failing, kubernetes resources are processed anyway.
+func degradeOnOpenCircuit(err error) error {
+	if errors.Is(err, azure.ErrCircuitOpen) {
+		return util.NewDegradedError(err)
+	}
+	return err
+}
+
+// captureResourceScale captures scale of node pool or replicas of container app and stores them in policy status.
+// Scale stored by previous attempt is kept, as resource could be already scaled to zero by it.
+func (ex *Executor) captureResourceScale(
+	ctx context.Context,
+	az azure.Interface,
+	policy *apis.StandSchedulePolicy,
+	resource *azure.Resource,
+) error {
+	if scale, ok := policy.Status.NodePools[resource.GetID()]; ok {
+		resource.SetScale(&scale)
+		return nil
+	}
+	if replicas, ok := policy.Status.ContainerApps[resource.GetID()]; ok {
+		resource.SetReplicas(&replicas)
+		return nil
+	}
+	if err := az.Capture(ctx, resource); err != nil {
+		return err
+	}
+	return ex.saveResourceScale(ctx, policy, resource)
+}
+
+// saveResourceScale stores in policy status scale of node pool or replicas of container app captured on shutdown.
+func (ex *Executor) saveResourceScale(ctx context.Context, policy *apis.StandSchedulePolicy, resource *azure.Resource) error {
+	scale, replicas := resource.GetScale(), resource.GetReplicas()
+	if scale == nil && replicas == nil {
+		return nil
+	}
+
+	ex.logger.Debug("Save resource scale",
+		zap.Stringer("resource", resource),
+		zap.Any("scale", scale),
+		zap.Any("replicas", replicas))
+
+	return ex.updateStatus(ctx, policy.Name, func(status *apis.StandSchedulePolicyStatus) {
+		if scale != nil {
+			if status.NodePools == nil {
+				status.NodePools = map[string]apis.NodePoolScale{}
+			}
+			status.NodePools[resource.GetID()] = *scale
+		}
+		if replicas != nil {
+			if status.ContainerApps == nil {
+				status.ContainerApps = map[string]apis.ContainerAppScale{}
+			}
+			status.ContainerApps[resource.GetID()] = *replicas
+		}
+	})
+}
+
+// deleteResourceScale removes from policy status scale of node pool or replicas of container app restored on startup.
+func (ex *Executor) deleteResourceScale(ctx context.Context, policy *apis.StandSchedulePolicy, resource *azure.Resource) error {
+	if resource.GetScale() == nil && resource.GetReplicas() == nil {
+		return nil
+	}
+
+	return ex.updateStatus(ctx, policy.Name, func(status *apis.StandSchedulePolicyStatus) {
+		delete(status.NodePools, resource.GetID())
+		delete(status.ContainerApps, resource.GetID())
+	})
+}
+
+// saveResourceStatuses stores in policy status power states of resources and outcomes of operations on them.
+func (ex *Executor) saveResourceStatuses(
+	ctx context.Context,
+	policy *apis.StandSchedulePolicy,
+	resources []*azure.Resource,
+	statuses []apis.AzureResourceStatus,
+) error {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	return ex.updateStatus(ctx, policy.Name, func(status *apis.StandSchedulePolicyStatus) {
+		if status.AzureResources == nil {
+			status.AzureResources = map[string]apis.AzureResourceStatus{}
+		}
+		for i, resource := range resources {
+			// resource is not operated, when its client could not be created
+			if statuses[i].PowerState == "" {
+				statuses[i].PowerState = string(azure.PowerStateUnknown)
+			}
+			status.AzureResources[resource.GetID()] = statuses[i]
+		}
+	})
+}
+
+// pruneResourceStatuses removes from policy status resources, which are no longer selected by policy.
+// Lead startup selects only part of resources, so status is pruned by main startup and shutdown.
+func (ex *Executor) pruneResourceStatuses(
+	ctx context.Context,
+	policy *apis.StandSchedulePolicy,
+	resources map[int64][]*azure.Resource,
+	cp *checkpoint,
+) error {
+	if cp == nil {
+		return nil
+	}
+
+	unselected := GetUnselectedAzureResources(policy.Status.AzureResources, resources)
+	if len(unselected) == 0 {
+		return nil
+	}
+
+	ex.logger.Debug("Prune statuses of azure resources", zap.Strings("resources", unselected))
+	return ex.updateStatus(ctx, policy.Name, func(status *apis.StandSchedulePolicyStatus) {
+		for _, id := range unselected {
+			delete(status.AzureResources, id)
+		}
+	})
+}
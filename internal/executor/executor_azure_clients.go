@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+)
+
+type (
+	// azureClients provides azure clients of policy, authorized with policy credentials (if any).
+	azureClients struct {
+		factory azure.Factory
+		cred    *azure.Credentials
+	}
+)
+
+var (
+	ErrCredentialsNotAllowed = errors.New("azure credentials of policy are not allowed")
+)
+
+// newAzureClients reads credentials referenced by policy, they are read on every execution to pick up rotated ones.
+// Policy is cluster scoped, so its credentials are read only from credentials namespace of controller, otherwise
+// anyone allowed to create policy could use secrets of any namespace.
+func (ex *Executor) newAzureClients(ctx context.Context, policy *apis.StandSchedulePolicy) (*azureClients, error) {
+	clients := &azureClients{factory: ex.azure}
+
+	ref := policy.Spec.Resources.AzureCredentials
+	if ref == nil {
+		return clients, nil
+	}
+
+	namespace, err := ex.getCredentialsNamespace(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ex.kube.CoreClient().
+		CoreV1().
+		Secrets(namespace).
+		Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get azure credentials secret %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	cred, err := azure.CredentialsFromSecret(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid azure credentials secret %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	cred.Source = fmt.Sprintf("%s/%s", namespace, ref.Name)
+	clients.cred = cred
+	return clients, nil
+}
+
+// getCredentialsNamespace returns namespace of credentials secret, credentials namespace is used when not specified.
+func (ex *Executor) getCredentialsNamespace(ref *core.SecretReference) (string, error) {
+	if ex.credentialsNamespace == "" {
+		return "", fmt.Errorf("%w: credentials namespace of controller is not configured", ErrCredentialsNotAllowed)
+	}
+	if ref.Namespace != "" && ref.Namespace != ex.credentialsNamespace {
+		return "", fmt.Errorf("%w: secret %s/%s is not in namespace %s",
+			ErrCredentialsNotAllowed, ref.Namespace, ref.Name, ex.credentialsNamespace)
+	}
+	return ex.credentialsNamespace, nil
+}
+
+// get returns client for subscription, controller subscription is used when not specified.
+func (c *azureClients) get(subscriptionId string) (azure.Interface, error) {
+	return c.factory.Get(subscriptionId, c.cred)
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+type (
+	// checkpoint tracks completed steps of execution in policy status, so execution could be resumed.
+	// Nil checkpoint tracks nothing.
+	checkpoint struct {
+		lock   sync.Mutex
+		ex     *Executor
+		policy string
+		state  *apis.ExecutionCheckpoint
+	}
+)
+
+func (ex *Executor) newCheckpoint(
+	policy *apis.StandSchedulePolicy,
+	st apis.ConditionScheduleType,
+	fireAt time.Time,
+) *checkpoint {
+	state := &apis.ExecutionCheckpoint{
+		Type:     st,
+		FireTime: meta.NewTime(fireAt),
+	}
+
+	// resume previous execution of the same schedule, unless it was cancelled
+	if prev := policy.Status.Checkpoint; isCheckpointOf(prev, st, fireAt) && !prev.Cancelled {
+		state = prev.DeepCopy()
+		ex.logger.Info("Resume execution of policy from checkpoint",
+			zap.String("policy_name", policy.Name),
+			zap.Strings("completed_steps", state.CompletedSteps))
+	}
+
+	return &checkpoint{
+		ex:     ex,
+		policy: policy.Name,
+		state:  state,
+	}
+}
+
+func isCheckpointOf(cp *apis.ExecutionCheckpoint, st apis.ConditionScheduleType, fireAt time.Time) bool {
+	return cp != nil && cp.Type == st && cp.FireTime.Unix() == fireAt.Unix()
+}
+
+func namespaceStep(namespace string) string {
+	return "namespace/" + namespace
+}
+
+func azureStep(priority int64) string {
+	return fmt.Sprintf("azure/%d", priority)
+}
+
+// Done checks that step already completed by previous attempt.
+func (c *checkpoint) Done(step string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.state.HasStep(step)
+}
+
+// Complete records step as completed, when it succeeded (or degraded, which is not retried).
+func (c *checkpoint) Complete(ctx context.Context, step string, err error) error {
+	if c == nil || err != nil && !util.IsDegraded(err) {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.state.HasStep(step) {
+		c.state.CompletedSteps = append(c.state.CompletedSteps, step)
+	}
+	return multierr.Append(err, c.save(ctx))
+}
+
+// Finish marks whole execution as completed, when it succeeded.
+func (c *checkpoint) Finish(ctx context.Context, err error) error {
+	if c == nil || err != nil && !util.IsDegraded(err) {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.state.Completed = true
+	return multierr.Append(err, c.save(ctx))
+}
+
+func (c *checkpoint) save(ctx context.Context) error {
+	return c.ex.updateStatus(ctx, c.policy, func(status *apis.StandSchedulePolicyStatus) {
+		status.Checkpoint = c.state.DeepCopy()
+	})
+}
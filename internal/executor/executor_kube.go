@@ -2,13 +2,15 @@ package executor
 
 import (
 	"context"
-	"strconv"
+	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
-	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -21,202 +23,354 @@ import (
 
 const (
 	_ResourceQuotaName          = "zero-quota"
+	_PolicyLabel                = apis.AnnotationPrefix + "/policy"
 	_ReplicasAnnotation         = apis.AnnotationPrefix + "/restore-replicas"
-	_WaitStsPodsTimeout         = time.Minute * 3
-	_WaitDeployPodsTimeout      = time.Minute * 1
-	_WaitTerminatingPodsTimeout = time.Minute * 1
-	_WaitPodsInterval           = time.Second * 15
+	_NodeSelectorAnnotation     = apis.AnnotationPrefix + "/restore-node-selector"
+	_NodeSelectorDisabledLabel  = apis.AnnotationPrefix + "/disabled"
+	_StartupOrderAnnotation     = apis.AnnotationPrefix + "/startup-order"
+	_SkipAnnotation             = apis.AnnotationPrefix + "/skip"
+	_ShutdownReplicasAnnotation = apis.AnnotationPrefix + "/shutdown-replicas"
+	_SuspendAnnotation          = apis.AnnotationPrefix + "/restore-suspend"
+	_DefaultEvictionConcurrency = 5
+	_DefaultEvictionFallback    = time.Minute * 2
+	_DefaultReadinessTimeout    = time.Minute * 5
 )
 
-func (ex *Executor) executeShutdownKube(ctx context.Context, policy *apis.StandSchedulePolicy) error {
+func (ex *Executor) executeShutdownKube(ctx context.Context, policy *apis.StandSchedulePolicy, cp *checkpoint) error {
 	namespaces, err := ex.fetchNamespaces(policy.Spec.TargetNamespaceFilter, true)
 	if err != nil {
 		return err
 	}
 
-	return util.ForEachE(namespaces, func(_ int, namespace string) error {
-		return multierr.Combine(
-			ex.scaleDownApps(ctx, namespace),
-			ex.createResourceQuota(ctx, namespace, policy),
-			ex.deleteExistingPods(ctx, namespace),
-			ex.waitTerminatingPods(ctx, namespace, _WaitTerminatingPodsTimeout),
+	return ex.forEachNamespace(policy, namespaces, func(namespace string) error {
+		step := namespaceStep(namespace)
+		if cp.Done(step) {
+			return nil
+		}
+
+		kept, err := ex.fetchKeptApps(namespace)
+		if err != nil {
+			return err
+		}
+
+		if _, err := ex.saveSnapshot(ctx, namespace, policy); err != nil {
+			return err
+		}
+
+		err = multierr.Combine(
+			ex.scaleDownApps(ctx, namespace, policy),
+			ex.disableDaemonSets(ctx, namespace),
+			ex.suspendCronJobs(ctx, namespace),
+			ex.createResourceQuota(ctx, namespace, policy, kept),
+			ex.deleteExistingPods(ctx, namespace, policy, kept),
+			ex.waitTerminatingPods(ctx, namespace, ex.getTimeouts(policy), kept),
 		)
+		return cp.Complete(ctx, step, err)
+	})
+}
+
+// forEachNamespace processes namespaces in order, or simultaneously when policy parallelism is greater than 1.
+func (ex *Executor) forEachNamespace(
+	policy *apis.StandSchedulePolicy,
+	namespaces []string,
+	f func(namespace string) error,
+) error {
+	if policy.Spec.Parallelism <= 1 {
+		return util.ForEachE(namespaces, func(_ int, namespace string) error {
+			return f(namespace)
+		})
+	}
+
+	return util.ForEachBoundedParallelE(namespaces, policy.Spec.Parallelism, func(_ int, namespace string) error {
+		return f(namespace)
 	})
 }
 
-func (ex *Executor) executeStartupKube(ctx context.Context, policy *apis.StandSchedulePolicy) error {
+func (ex *Executor) enforceShutdownKube(ctx context.Context, policy *apis.StandSchedulePolicy, namespace string) error {
+	// snapshot is saved first, so startup restores new desired replicas of changed workloads
+	if _, err := ex.saveSnapshot(ctx, namespace, policy); err != nil {
+		return err
+	}
+	return multierr.Append(
+		ex.scaleDownApps(ctx, namespace, policy),
+		ex.suspendCronJobs(ctx, namespace),
+	)
+}
+
+func (ex *Executor) executeStartupKube(ctx context.Context, policy *apis.StandSchedulePolicy, cp *checkpoint) error {
 	namespaces, err := ex.fetchNamespaces(policy.Spec.TargetNamespaceFilter, false)
 	if err != nil {
 		return err
 	}
 
-	return util.ForEachE(namespaces, func(_ int, namespace string) error {
-		return multierr.Combine(
-			ex.deleteResourceQuota(ctx, namespace),
-			ex.scaleUpApps(ctx, namespace),
+	return ex.forEachNamespace(policy, namespaces, func(namespace string) error {
+		step := namespaceStep(namespace)
+		if cp.Done(step) {
+			return nil
+		}
+
+		snapshot, err := ex.loadSnapshot(ctx, namespace, policy)
+		if err != nil {
+			return err
+		}
+
+		err = multierr.Combine(
+			ex.deleteResourceQuota(ctx, namespace, policy),
+			ex.enableDaemonSets(ctx, namespace, snapshot),
+			ex.restoreAutoscalers(ctx, namespace, snapshot),
+			ex.scaleUpApps(ctx, namespace, policy, snapshot),
+			ex.resumeCronJobs(ctx, namespace, snapshot),
+			ex.waitAllReadyApps(ctx, namespace, policy),
 		)
+
+		// keep snapshot for retry, unless workloads were restored and only readiness is degraded
+		if err != nil && !util.IsDegraded(err) {
+			return err
+		}
+		return cp.Complete(ctx, step, multierr.Append(err, ex.deleteSnapshot(ctx, namespace, policy)))
 	})
 }
 
-func (ex *Executor) createResourceQuota(ctx context.Context, namespace string, policy *apis.StandSchedulePolicy) error {
+func (ex *Executor) createResourceQuota(
+	ctx context.Context,
+	namespace string,
+	policy *apis.StandSchedulePolicy,
+	kept *KeptApps,
+) error {
+	name := GetResourceQuotaName(policy.Spec.Quota)
+
 	ex.logger.Debug("Create resource quota in namespace",
-		zap.String("quota", _ResourceQuotaName),
-		zap.String("namespace", namespace))
+		zap.String("quota", name),
+		zap.String("namespace", namespace),
+		zap.Int64("pods", kept.Pods))
 
-	quota := &core.ResourceQuota{
-		ObjectMeta: meta.ObjectMeta{
-			Name:      _ResourceQuotaName,
-			Namespace: namespace,
-			OwnerReferences: []meta.OwnerReference{
-				{
-					APIVersion: apis.GroupVersion.String(),
-					Kind:       "StandSchedulePolicy",
-					Name:       policy.Name,
-					UID:        policy.UID,
+	quotaLabels := map[string]string{}
+	for key, value := range policy.Spec.Quota.Labels {
+		quotaLabels[key] = value
+	}
+	quotaLabels[_PolicyLabel] = policy.Name
+
+	hard := core.ResourceList{}
+	for resourceName, quantity := range policy.Spec.Quota.Hard {
+		hard[resourceName] = quantity.DeepCopy()
+	}
+	hard[core.ResourcePods] = *resource.NewQuantity(kept.Pods, resource.DecimalSI)
+
+	existing, err := ex.kube.CoreClient().
+		CoreV1().
+		ResourceQuotas(namespace).
+		Get(ctx, name, meta.GetOptions{})
+
+	switch {
+	case errors.IsNotFound(err):
+		quota := &core.ResourceQuota{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    quotaLabels,
+				OwnerReferences: []meta.OwnerReference{
+					{
+						APIVersion: apis.GroupVersion.String(),
+						Kind:       "StandSchedulePolicy",
+						Name:       policy.Name,
+						UID:        policy.UID,
+					},
 				},
 			},
-		},
-		Spec: core.ResourceQuotaSpec{
-			Hard: core.ResourceList{
-				core.ResourcePods: resource.MustParse("0"),
+			Spec: core.ResourceQuotaSpec{
+				Hard: hard,
 			},
-		},
+		}
+
+		_, err = ex.kube.CoreClient().
+			CoreV1().
+			ResourceQuotas(namespace).
+			Create(ctx, quota, meta.CreateOptions{})
+
+		return kubernetes.IgnoreAlreadyExists(err)
+	case err != nil:
+		return err
+	case !IsOwnedByPolicy(existing.ObjectMeta, policy):
+		return fmt.Errorf("resource quota %s in namespace %s is not managed by policy %s", name, namespace, policy.Name)
 	}
 
-	_, err := ex.kube.CoreClient().
+	quota := existing.DeepCopy()
+	for key, value := range quotaLabels {
+		kubernetes.SetLabel(&quota.ObjectMeta, key, value)
+	}
+	quota.Spec.Hard = hard
+
+	_, err = ex.kube.CoreClient().
 		CoreV1().
 		ResourceQuotas(namespace).
-		Create(ctx, quota, meta.CreateOptions{})
+		Update(ctx, quota, meta.UpdateOptions{})
 
-	return kubernetes.IgnoreAlreadyExists(err)
+	return err
 }
 
-func (ex *Executor) scaleDownApps(ctx context.Context, namespace string) error {
-	ex.logger.Debug("ScaleDown deployments and statefulSets in namespace", zap.String("namespace", namespace))
+func (ex *Executor) deleteExistingPods(
+	ctx context.Context,
+	namespace string,
+	policy *apis.StandSchedulePolicy,
+	kept *KeptApps,
+) error {
+	if policy.Spec.Eviction.Enabled {
+		return ex.evictExistingPods(ctx, namespace, policy, kept)
+	}
 
-	deployments, err := ex.lister.Deployments.Deployments(namespace).List(labels.Everything())
-	if err != nil {
+	if kept.Empty() {
+		ex.logger.Debug("Delete all existing pods in namespace", zap.String("namespace", namespace))
+
+		return ex.kube.CoreClient().
+			CoreV1().
+			Pods(namespace).
+			DeleteCollection(ctx, meta.DeleteOptions{}, meta.ListOptions{})
+	}
+
+	ex.logger.Debug("Delete existing pods except kept ones in namespace", zap.String("namespace", namespace))
+
+	podList, err := ex.listPods(ctx, namespace)
+	if err != nil || podList == nil {
 		return err
 	}
-	deployments = util.Where(deployments, func(_ int, d *apps.Deployment) bool {
-		return d.Spec.Replicas != nil && *d.Spec.Replicas != 0
+
+	pods := util.Where(podList.Items, func(_ int, pod core.Pod) bool {
+		return !kept.Has(&pod)
 	})
-	ex.logger.Debug("Deployments count", zap.Int("count", len(deployments)))
 
-	statefulSets, err := ex.lister.StatefulSets.StatefulSets(namespace).List(labels.Everything())
-	if err != nil {
+	return util.ForEachE(pods, func(_ int, pod core.Pod) error {
+		err := ex.kube.CoreClient().
+			CoreV1().
+			Pods(namespace).
+			Delete(ctx, pod.Name, meta.DeleteOptions{})
+		return kubernetes.IgnoreNotFound(err)
+	})
+}
+
+func (ex *Executor) evictExistingPods(
+	ctx context.Context,
+	namespace string,
+	policy *apis.StandSchedulePolicy,
+	kept *KeptApps,
+) error {
+	eviction := policy.Spec.Eviction
+
+	ex.logger.Debug("Evict all existing pods in namespace", zap.String("namespace", namespace))
+
+	podList, err := ex.listPods(ctx, namespace)
+	if err != nil || podList == nil {
 		return err
 	}
-	statefulSets = util.Where(statefulSets, func(_ int, s *apps.StatefulSet) bool {
-		return s.Spec.Replicas != nil && *s.Spec.Replicas != 0
+
+	pods := util.Where(podList.Items, func(_ int, pod core.Pod) bool {
+		return !kept.Has(&pod)
 	})
-	ex.logger.Debug("ScaleSets count", zap.Int("count", len(statefulSets)))
-
-	return multierr.Combine(
-		util.ForEachE(deployments, func(_ int, deployment *apps.Deployment) error {
-			replicas := *deployment.Spec.Replicas
-			deployment.Spec.Replicas = util.Pointer(int32(0))
-			kubernetes.SetAnnotation(deployment.ObjectMeta, _ReplicasAnnotation, strconv.Itoa(int(replicas)))
-
-			ex.logger.Debug("ScaleDown deployment in namespace",
-				zap.String("namespace", namespace),
-				zap.String("deployment", deployment.Name))
-			return ex.updateDeployment(ctx, deployment)
-		}),
-		util.ForEachE(statefulSets, func(_ int, sts *apps.StatefulSet) error {
-			replicas := *sts.Spec.Replicas
-			sts.Spec.Replicas = util.Pointer(int32(0))
-			kubernetes.SetAnnotation(sts.ObjectMeta, _ReplicasAnnotation, strconv.Itoa(int(replicas)))
-
-			ex.logger.Debug("ScaleDown statefulset in namespace",
-				zap.String("namespace", namespace),
-				zap.String("statefulset", sts.Name))
-			return ex.updateStatefulSet(ctx, sts)
-		}),
-	)
-}
 
-func (ex *Executor) deleteExistingPods(ctx context.Context, namespace string) error {
-	ex.logger.Debug("Delete all existing pods in namespace", zap.String("namespace", namespace))
+	concurrency := eviction.Concurrency
+	if concurrency < 1 {
+		concurrency = _DefaultEvictionConcurrency
+	}
 
-	return ex.kube.CoreClient().
-		CoreV1().
-		Pods(namespace).
-		DeleteCollection(ctx, meta.DeleteOptions{}, meta.ListOptions{})
+	fallback := eviction.FallbackTimeout.Duration
+	if fallback <= 0 {
+		fallback = _DefaultEvictionFallback
+	}
+
+	return util.ForEachBoundedParallelE(pods, concurrency, func(_ int, pod core.Pod) error {
+		return ex.evictPod(ctx, &pod, fallback, ex.getTimeouts(policy).WaitPodsInterval.Duration)
+	})
 }
 
-func (ex *Executor) deleteResourceQuota(ctx context.Context, namespace string) error {
-	ex.logger.Debug("Delete resource quota in namespace",
-		zap.String("quota", _ResourceQuotaName),
-		zap.String("namespace", namespace))
+func (ex *Executor) evictPod(ctx context.Context, pod *core.Pod, fallback, interval time.Duration) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
 
-	err := ex.kube.CoreClient().
+	err := wait.PollImmediate(interval, fallback, func() (bool, error) {
+		ex.logger.Debug("Evict pod in namespace",
+			zap.String("namespace", pod.Namespace),
+			zap.String("pod", pod.Name))
+
+		err := ex.kube.CoreClient().
+			CoreV1().
+			Pods(pod.Namespace).
+			EvictV1(ctx, eviction)
+
+		switch {
+		case err == nil, errors.IsNotFound(err):
+			return true, nil
+		case errors.IsTooManyRequests(err):
+			ex.logger.Debug("Eviction of pod refused",
+				zap.String("namespace", pod.Namespace),
+				zap.String("pod", pod.Name),
+				zap.Error(err))
+			return false, nil
+		default:
+			return false, err
+		}
+	})
+
+	if !util.IsMatchedError(err, wait.ErrWaitTimeout) {
+		return err
+	}
+
+	ex.logger.Warn("Eviction of pod refused until timeout, fallback to deletion",
+		zap.String("namespace", pod.Namespace),
+		zap.String("pod", pod.Name),
+		zap.Stringer("timeout", fallback))
+
+	err = ex.kube.CoreClient().
 		CoreV1().
-		ResourceQuotas(namespace).
-		Delete(ctx, _ResourceQuotaName, meta.DeleteOptions{})
+		Pods(pod.Namespace).
+		Delete(ctx, pod.Name, meta.DeleteOptions{})
 
 	return kubernetes.IgnoreNotFound(err)
 }
 
-func (ex *Executor) scaleUpApps(ctx context.Context, namespace string) error {
-	ex.logger.Debug("ScaleUp deployments and statefulSets in namespace", zap.String("namespace", namespace))
+func (ex *Executor) deleteResourceQuota(ctx context.Context, namespace string, policy *apis.StandSchedulePolicy) error {
+	name := GetResourceQuotaName(policy.Spec.Quota)
+
+	ex.logger.Debug("Delete resource quota in namespace",
+		zap.String("quota", name),
+		zap.String("namespace", namespace))
 
-	deployments, err := ex.lister.Deployments.Deployments(namespace).List(labels.Everything())
+	quota, err := ex.kube.CoreClient().
+		CoreV1().
+		ResourceQuotas(namespace).
+		Get(ctx, name, meta.GetOptions{})
 	if err != nil {
-		return err
+		return kubernetes.IgnoreNotFound(err)
 	}
-	deployments = util.Where(deployments, func(_ int, d *apps.Deployment) bool {
-		replicas := d.Spec.Replicas != nil && *d.Spec.Replicas == 0
-		_, scaled := d.Annotations[_ReplicasAnnotation]
-		return replicas && scaled
-	})
-	ex.logger.Debug("Deployments count", zap.Int("count", len(deployments)))
 
-	statefulSets, err := ex.lister.StatefulSets.StatefulSets(namespace).List(labels.Everything())
-	if err != nil {
-		return err
+	if !IsOwnedByPolicy(quota.ObjectMeta, policy) {
+		return fmt.Errorf("resource quota %s in namespace %s is not managed by policy %s", name, namespace, policy.Name)
 	}
-	statefulSets = util.Where(statefulSets, func(_ int, s *apps.StatefulSet) bool {
-		replicas := s.Spec.Replicas != nil && *s.Spec.Replicas == 0
-		_, scaled := s.Annotations[_ReplicasAnnotation]
-		return replicas && scaled
-	})
-	ex.logger.Debug("ScaleSets count", zap.Int("count", len(statefulSets)))
-
-	return multierr.Combine(
-		util.ForEachE(statefulSets, func(_ int, sts *apps.StatefulSet) error {
-			val, _ := kubernetes.GetAnnotation(sts.ObjectMeta, _ReplicasAnnotation)
-			replicas, _ := strconv.Atoi(val)
-			sts.Spec.Replicas = util.Pointer(int32(replicas))
-			delete(sts.ObjectMeta.Annotations, _ReplicasAnnotation)
-
-			ex.logger.Debug("ScaleUp statefulset in namespace",
-				zap.String("namespace", namespace),
-				zap.String("statefulset", sts.Name))
-			return ex.updateStatefulSet(ctx, sts)
-		}),
-		ex.waitPendingPods(ctx, namespace, len(statefulSets), _WaitStsPodsTimeout),
-		util.ForEachE(deployments, func(_ int, deployment *apps.Deployment) error {
-			val, _ := kubernetes.GetAnnotation(deployment.ObjectMeta, _ReplicasAnnotation)
-			replicas, _ := strconv.Atoi(val)
-			deployment.Spec.Replicas = util.Pointer(int32(replicas))
-			delete(deployment.ObjectMeta.Annotations, _ReplicasAnnotation)
-
-			ex.logger.Debug("ScaleUp deployment in namespace",
-				zap.String("namespace", namespace),
-				zap.String("deployment", deployment.Name))
-			return ex.updateDeployment(ctx, deployment)
-		}),
-		ex.waitPendingPods(ctx, namespace, len(deployments), _WaitDeployPodsTimeout),
-	)
+
+	err = ex.kube.CoreClient().
+		CoreV1().
+		ResourceQuotas(namespace).
+		Delete(ctx, name, meta.DeleteOptions{
+			Preconditions: &meta.Preconditions{UID: &quota.UID},
+		})
+
+	return kubernetes.IgnoreNotFound(err)
 }
 
-func (ex *Executor) waitPendingPods(ctx context.Context, namespace string, appCount int, timeout time.Duration) error {
+func (ex *Executor) waitPendingPods(
+	ctx context.Context,
+	namespace string,
+	appCount int,
+	timeout time.Duration,
+	interval time.Duration,
+) error {
 	if appCount == 0 {
 		return nil
 	}
 
-	err := wait.Poll(_WaitPodsInterval, timeout, func() (bool, error) {
+	err := wait.Poll(interval, timeout, func() (bool, error) {
 		ex.logger.Debug("Wait pods in namespace", zap.String("namespace", namespace))
 
 		podList, err := ex.listPods(ctx, namespace)
@@ -236,8 +390,16 @@ func (ex *Executor) waitPendingPods(ctx context.Context, namespace string, appCo
 	return util.IgnoreMatchedError(err, wait.ErrWaitTimeout)
 }
 
-func (ex *Executor) waitTerminatingPods(ctx context.Context, namespace string, timeout time.Duration) error {
-	err := wait.Poll(_WaitPodsInterval, timeout, func() (bool, error) {
+func (ex *Executor) waitTerminatingPods(
+	ctx context.Context,
+	namespace string,
+	timeouts apis.TimeoutsSpec,
+	kept *KeptApps,
+) error {
+	var terminating []string
+
+	timeout := timeouts.WaitTerminatingPods.Duration
+	err := wait.Poll(timeouts.WaitPodsInterval.Duration, timeout, func() (bool, error) {
 		ex.logger.Debug("Wait pods until terminated state in namespace", zap.String("namespace", namespace))
 
 		podList, err := ex.listPods(ctx, namespace)
@@ -245,18 +407,26 @@ func (ex *Executor) waitTerminatingPods(ctx context.Context, namespace string, t
 			return false, err
 		}
 
-		for _, pod := range podList.Items {
-			for _, cs := range pod.Status.ContainerStatuses {
-				if cs.State.Terminated == nil {
-					return false, nil
-				}
-			}
-		}
+		terminating = util.Project(
+			util.Where(podList.Items, func(_ int, pod core.Pod) bool {
+				return !kept.Has(&pod) && !IsPodTerminated(&pod)
+			}),
+			func(_ int, pod core.Pod) string {
+				return pod.Name
+			})
 
-		return true, nil
+		return len(terminating) == 0, nil
 	})
 
-	return util.IgnoreMatchedError(err, wait.ErrWaitTimeout)
+	if util.IsMatchedError(err, wait.ErrWaitTimeout) {
+		ex.logger.Warn("Pods remain in terminating state in namespace",
+			zap.String("namespace", namespace),
+			zap.Strings("pods", terminating))
+		return util.NewDegradedError(fmt.Errorf("pods in namespace %s remain terminating after %s: %s",
+			namespace, timeout, strings.Join(terminating, ", ")))
+	}
+
+	return err
 }
 
 func (ex *Executor) listPods(ctx context.Context, namespace string) (*core.PodList, error) {
@@ -267,22 +437,6 @@ func (ex *Executor) listPods(ctx context.Context, namespace string) (*core.PodLi
 	return list, kubernetes.IgnoreTimeout(err)
 }
 
-func (ex *Executor) updateDeployment(ctx context.Context, deployment *apps.Deployment) error {
-	_, err := ex.kube.CoreClient().
-		AppsV1().
-		Deployments(deployment.Namespace).
-		Update(ctx, deployment, meta.UpdateOptions{})
-	return err
-}
-
-func (ex *Executor) updateStatefulSet(ctx context.Context, sts *apps.StatefulSet) error {
-	_, err := ex.kube.CoreClient().
-		AppsV1().
-		StatefulSets(sts.Namespace).
-		Update(ctx, sts, meta.UpdateOptions{})
-	return err
-}
-
 func (ex *Executor) fetchNamespaces(filter string, reverse bool) ([]string, error) {
 	list, err := ex.lister.Namespaces.List(labels.Everything())
 	if err != nil {
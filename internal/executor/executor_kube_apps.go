@@ -0,0 +1,474 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	batch "k8s.io/api/batch/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/kubernetes"
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+type (
+	appsCondition struct {
+		name       string
+		deployment func(*apps.Deployment) bool
+		sts        func(*apps.StatefulSet) bool
+		timeout    time.Duration
+	}
+)
+
+func (ex *Executor) scaleDownApps(ctx context.Context, namespace string, policy *apis.StandSchedulePolicy) error {
+	ex.logger.Debug("ScaleDown deployments and statefulSets in namespace", zap.String("namespace", namespace))
+
+	deployments, err := ex.lister.Deployments.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	deployments = util.Where(deployments, func(_ int, d *apps.Deployment) bool {
+		return !IsSkipped(d.ObjectMeta) && d.Spec.Replicas != nil && *d.Spec.Replicas > GetShutdownReplicas(d.ObjectMeta)
+	})
+	ex.logger.Debug("Deployments count", zap.Int("count", len(deployments)))
+
+	statefulSets, err := ex.lister.StatefulSets.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	statefulSets = util.Where(statefulSets, func(_ int, s *apps.StatefulSet) bool {
+		return !IsSkipped(s.ObjectMeta) && s.Spec.Replicas != nil && *s.Spec.Replicas > GetShutdownReplicas(s.ObjectMeta)
+	})
+	ex.logger.Debug("ScaleSets count", zap.Int("count", len(statefulSets)))
+
+	// shutdown waves in reverse order, the latest started apps are stopped first
+	waves := util.Reverse(GroupAppsByOrder(deployments, statefulSets, ex.getStartupOrder(policy)))
+
+	return util.ForEachE(waves, func(i int, wave *AppsWave) error {
+		err := ex.scaleDownWave(ctx, namespace, wave)
+		if i == len(waves)-1 {
+			return err
+		}
+		return multierr.Append(err, ex.waitScaledDownApps(ctx, namespace, wave, policy))
+	})
+}
+
+func (ex *Executor) scaleDownWave(ctx context.Context, namespace string, wave *AppsWave) error {
+	ex.logger.Debug("ScaleDown wave in namespace",
+		zap.String("namespace", namespace),
+		zap.Int("order", wave.Order))
+
+	return multierr.Combine(
+		util.ForEachE(wave.Deployments, func(_ int, deployment *apps.Deployment) error {
+			replicas := *deployment.Spec.Replicas
+			deployment = deployment.DeepCopy()
+			deployment.Spec.Replicas = util.Pointer(GetShutdownReplicas(deployment.ObjectMeta))
+			kubernetes.SetAnnotation(&deployment.ObjectMeta, _ReplicasAnnotation, strconv.Itoa(int(replicas)))
+
+			ex.logger.Debug("ScaleDown deployment in namespace",
+				zap.String("namespace", namespace),
+				zap.String("deployment", deployment.Name))
+			return ex.updateDeployment(ctx, deployment)
+		}),
+		util.ForEachE(wave.StatefulSets, func(_ int, sts *apps.StatefulSet) error {
+			replicas := *sts.Spec.Replicas
+			sts = sts.DeepCopy()
+			sts.Spec.Replicas = util.Pointer(GetShutdownReplicas(sts.ObjectMeta))
+			kubernetes.SetAnnotation(&sts.ObjectMeta, _ReplicasAnnotation, strconv.Itoa(int(replicas)))
+
+			ex.logger.Debug("ScaleDown statefulset in namespace",
+				zap.String("namespace", namespace),
+				zap.String("statefulset", sts.Name))
+			return ex.updateStatefulSet(ctx, sts)
+		}),
+	)
+}
+
+func (ex *Executor) disableDaemonSets(ctx context.Context, namespace string) error {
+	ex.logger.Debug("Disable daemonSets in namespace", zap.String("namespace", namespace))
+
+	daemonSets, err := ex.lister.DaemonSets.DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	daemonSets = util.Where(daemonSets, func(_ int, ds *apps.DaemonSet) bool {
+		return !IsDaemonSetDisabled(ds) && !IsSkipped(ds.ObjectMeta)
+	})
+	ex.logger.Debug("DaemonSets count", zap.Int("count", len(daemonSets)))
+
+	return util.ForEachE(daemonSets, func(_ int, ds *apps.DaemonSet) error {
+		selector, err := json.Marshal(ds.Spec.Template.Spec.NodeSelector)
+		if err != nil {
+			return err
+		}
+
+		ds = ds.DeepCopy()
+		ds.Spec.Template.Spec.NodeSelector = map[string]string{_NodeSelectorDisabledLabel: "true"}
+		kubernetes.SetAnnotation(&ds.ObjectMeta, _NodeSelectorAnnotation, string(selector))
+
+		ex.logger.Debug("Disable daemonset in namespace",
+			zap.String("namespace", namespace),
+			zap.String("daemonset", ds.Name))
+		return ex.updateDaemonSet(ctx, ds)
+	})
+}
+
+func (ex *Executor) scaleUpApps(
+	ctx context.Context,
+	namespace string,
+	policy *apis.StandSchedulePolicy,
+	snapshot *Snapshot,
+) error {
+	ex.logger.Debug("ScaleUp deployments and statefulSets in namespace", zap.String("namespace", namespace))
+
+	deployments, err := ex.lister.Deployments.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	// workloads scaled down by shutdown are restored, even when shutdown replicas annotation changed since then
+	deployments = util.Where(deployments, func(_ int, d *apps.Deployment) bool {
+		_, scaled := snapshot.GetDeploymentReplicas(d)
+		return scaled
+	})
+	ex.logger.Debug("Deployments count", zap.Int("count", len(deployments)))
+
+	statefulSets, err := ex.lister.StatefulSets.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	statefulSets = util.Where(statefulSets, func(_ int, s *apps.StatefulSet) bool {
+		_, scaled := snapshot.GetStatefulSetReplicas(s)
+		return scaled
+	})
+	ex.logger.Debug("ScaleSets count", zap.Int("count", len(statefulSets)))
+
+	waves := GroupAppsByOrder(deployments, statefulSets, ex.getStartupOrder(policy))
+
+	return util.ForEachE(waves, func(i int, wave *AppsWave) error {
+		err := ex.scaleUpWave(ctx, namespace, wave, snapshot, ex.getTimeouts(policy))
+		if i == len(waves)-1 {
+			return err
+		}
+		return multierr.Append(err, ex.waitReadyApps(ctx, namespace, wave, policy, ex.getTimeouts(policy).WaitWave.Duration))
+	})
+}
+
+func (ex *Executor) scaleUpWave(
+	ctx context.Context,
+	namespace string,
+	wave *AppsWave,
+	snapshot *Snapshot,
+	timeouts apis.TimeoutsSpec,
+) error {
+	interval := timeouts.WaitPodsInterval.Duration
+
+	ex.logger.Debug("ScaleUp wave in namespace",
+		zap.String("namespace", namespace),
+		zap.Int("order", wave.Order))
+
+	return multierr.Combine(
+		util.ForEachE(wave.StatefulSets, func(_ int, sts *apps.StatefulSet) error {
+			replicas, _ := snapshot.GetStatefulSetReplicas(sts)
+			sts = sts.DeepCopy()
+			sts.Spec.Replicas = util.Pointer(replicas)
+			delete(sts.ObjectMeta.Annotations, _ReplicasAnnotation)
+
+			ex.logger.Debug("ScaleUp statefulset in namespace",
+				zap.String("namespace", namespace),
+				zap.String("statefulset", sts.Name))
+			return ex.updateStatefulSet(ctx, sts)
+		}),
+		ex.waitPendingPods(ctx, namespace, len(wave.StatefulSets), timeouts.WaitStatefulSetPods.Duration, interval),
+		util.ForEachE(wave.Deployments, func(_ int, deployment *apps.Deployment) error {
+			replicas, _ := snapshot.GetDeploymentReplicas(deployment)
+			deployment = deployment.DeepCopy()
+			deployment.Spec.Replicas = util.Pointer(replicas)
+			delete(deployment.ObjectMeta.Annotations, _ReplicasAnnotation)
+
+			ex.logger.Debug("ScaleUp deployment in namespace",
+				zap.String("namespace", namespace),
+				zap.String("deployment", deployment.Name))
+			return ex.updateDeployment(ctx, deployment)
+		}),
+		ex.waitPendingPods(ctx, namespace, len(wave.Deployments), timeouts.WaitDeploymentPods.Duration, interval),
+	)
+}
+
+func (ex *Executor) enableDaemonSets(ctx context.Context, namespace string, snapshot *Snapshot) error {
+	ex.logger.Debug("Enable daemonSets in namespace", zap.String("namespace", namespace))
+
+	daemonSets, err := ex.lister.DaemonSets.DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	daemonSets = util.Where(daemonSets, func(_ int, ds *apps.DaemonSet) bool {
+		_, disabled := snapshot.GetDaemonSetNodeSelector(ds)
+		return disabled
+	})
+	ex.logger.Debug("DaemonSets count", zap.Int("count", len(daemonSets)))
+
+	return util.ForEachE(daemonSets, func(_ int, ds *apps.DaemonSet) error {
+		selector, _ := snapshot.GetDaemonSetNodeSelector(ds)
+
+		ds = ds.DeepCopy()
+		ds.Spec.Template.Spec.NodeSelector = selector
+		delete(ds.ObjectMeta.Annotations, _NodeSelectorAnnotation)
+
+		ex.logger.Debug("Enable daemonset in namespace",
+			zap.String("namespace", namespace),
+			zap.String("daemonset", ds.Name))
+		return ex.updateDaemonSet(ctx, ds)
+	})
+}
+
+func (ex *Executor) suspendCronJobs(ctx context.Context, namespace string) error {
+	ex.logger.Debug("Suspend cronJobs in namespace", zap.String("namespace", namespace))
+
+	cronJobs, err := ex.lister.CronJobs.CronJobs(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	cronJobs = util.Where(cronJobs, func(_ int, cj *batch.CronJob) bool {
+		return !IsCronJobSuspended(cj) && !IsSkipped(cj.ObjectMeta)
+	})
+	ex.logger.Debug("CronJobs count", zap.Int("count", len(cronJobs)))
+
+	return util.ForEachE(cronJobs, func(_ int, cj *batch.CronJob) error {
+		cj = cj.DeepCopy()
+		cj.Spec.Suspend = util.Pointer(true)
+		kubernetes.SetAnnotation(&cj.ObjectMeta, _SuspendAnnotation, "false")
+
+		ex.logger.Debug("Suspend cronjob in namespace",
+			zap.String("namespace", namespace),
+			zap.String("cronjob", cj.Name))
+		return ex.updateCronJob(ctx, cj)
+	})
+}
+
+func (ex *Executor) resumeCronJobs(ctx context.Context, namespace string, snapshot *Snapshot) error {
+	ex.logger.Debug("Resume cronJobs in namespace", zap.String("namespace", namespace))
+
+	cronJobs, err := ex.lister.CronJobs.CronJobs(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	cronJobs = util.Where(cronJobs, func(_ int, cj *batch.CronJob) bool {
+		return snapshot.IsCronJobSuspendedByShutdown(cj)
+	})
+	ex.logger.Debug("CronJobs count", zap.Int("count", len(cronJobs)))
+
+	return util.ForEachE(cronJobs, func(_ int, cj *batch.CronJob) error {
+		cj = cj.DeepCopy()
+		cj.Spec.Suspend = util.Pointer(false)
+		delete(cj.ObjectMeta.Annotations, _SuspendAnnotation)
+
+		ex.logger.Debug("Resume cronjob in namespace",
+			zap.String("namespace", namespace),
+			zap.String("cronjob", cj.Name))
+		return ex.updateCronJob(ctx, cj)
+	})
+}
+
+// restoreAutoscalers reverts bounds of autoscalers changed while stand was stopped, before apps are scaled up.
+func (ex *Executor) restoreAutoscalers(ctx context.Context, namespace string, snapshot *Snapshot) error {
+	ex.logger.Debug("Restore autoscalers in namespace", zap.String("namespace", namespace))
+
+	autoscalers, err := ex.lister.Autoscalers.HorizontalPodAutoscalers(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	autoscalers = util.Where(autoscalers, func(_ int, hpa *autoscaling.HorizontalPodAutoscaler) bool {
+		_, changed := snapshot.GetAutoscalerBounds(hpa)
+		return changed
+	})
+	ex.logger.Debug("Autoscalers count", zap.Int("count", len(autoscalers)))
+
+	return util.ForEachE(autoscalers, func(_ int, hpa *autoscaling.HorizontalPodAutoscaler) error {
+		bounds, _ := snapshot.GetAutoscalerBounds(hpa)
+
+		hpa = hpa.DeepCopy()
+		hpa.Spec.MinReplicas = bounds.MinReplicas
+		hpa.Spec.MaxReplicas = bounds.MaxReplicas
+
+		ex.logger.Debug("Restore autoscaler in namespace",
+			zap.String("namespace", namespace),
+			zap.String("autoscaler", hpa.Name))
+		return ex.updateAutoscaler(ctx, hpa)
+	})
+}
+
+// waitReadyApps waits until apps from wave (or all apps in namespace, when wave not specified) are ready.
+func (ex *Executor) waitReadyApps(
+	ctx context.Context,
+	namespace string,
+	wave *AppsWave,
+	policy *apis.StandSchedulePolicy,
+	timeout time.Duration,
+) error {
+	return ex.waitApps(ctx, namespace, wave, policy, appsCondition{
+		name:       "ready",
+		deployment: IsDeploymentReady,
+		sts:        IsStatefulSetReady,
+		timeout:    timeout,
+	})
+}
+
+// waitScaledDownApps waits until apps from wave have no running replicas.
+func (ex *Executor) waitScaledDownApps(
+	ctx context.Context,
+	namespace string,
+	wave *AppsWave,
+	policy *apis.StandSchedulePolicy,
+) error {
+	return ex.waitApps(ctx, namespace, wave, policy, appsCondition{
+		name:       "scaled down",
+		deployment: IsDeploymentScaledDown,
+		sts:        IsStatefulSetScaledDown,
+		timeout:    ex.getTimeouts(policy).WaitWave.Duration,
+	})
+}
+
+func (ex *Executor) waitApps(
+	ctx context.Context,
+	namespace string,
+	wave *AppsWave,
+	policy *apis.StandSchedulePolicy,
+	condition appsCondition,
+) error {
+	timeout := condition.timeout
+	var notMatched []string
+
+	err := wait.PollImmediate(ex.getTimeouts(policy).WaitPodsInterval.Duration, timeout, func() (bool, error) {
+		ex.logger.Debug("Wait deployments and statefulSets in namespace",
+			zap.String("namespace", namespace),
+			zap.String("state", condition.name))
+
+		deployments, err := ex.kube.CoreClient().
+			AppsV1().
+			Deployments(namespace).
+			List(ctx, meta.ListOptions{})
+		if err != nil {
+			return false, kubernetes.IgnoreTimeout(err)
+		}
+
+		statefulSets, err := ex.kube.CoreClient().
+			AppsV1().
+			StatefulSets(namespace).
+			List(ctx, meta.ListOptions{})
+		if err != nil {
+			return false, kubernetes.IgnoreTimeout(err)
+		}
+
+		notMatched = append(
+			util.Project(
+				util.Where(deployments.Items, func(_ int, d apps.Deployment) bool {
+					return wave.HasDeployment(d.Name) && !condition.deployment(&d)
+				}),
+				func(_ int, d apps.Deployment) string {
+					return "deployment/" + d.Name
+				}),
+			util.Project(
+				util.Where(statefulSets.Items, func(_ int, s apps.StatefulSet) bool {
+					return wave.HasStatefulSet(s.Name) && !condition.sts(&s)
+				}),
+				func(_ int, s apps.StatefulSet) string {
+					return "statefulset/" + s.Name
+				})...,
+		)
+
+		return len(notMatched) == 0, nil
+	})
+
+	if util.IsMatchedError(err, wait.ErrWaitTimeout) {
+		ex.logger.Warn("Workloads not reached state in namespace",
+			zap.String("namespace", namespace),
+			zap.String("state", condition.name),
+			zap.Strings("workloads", notMatched))
+		return util.NewDegradedError(fmt.Errorf("workloads in namespace %s not %s after %s: %s",
+			namespace, condition.name, timeout, strings.Join(notMatched, ", ")))
+	}
+
+	return err
+}
+
+func (ex *Executor) waitAllReadyApps(ctx context.Context, namespace string, policy *apis.StandSchedulePolicy) error {
+	if !policy.Spec.Readiness.Enabled {
+		return nil
+	}
+
+	timeout := policy.Spec.Readiness.Timeout.Duration
+	if timeout <= 0 {
+		timeout = _DefaultReadinessTimeout
+	}
+	return ex.waitReadyApps(ctx, namespace, nil, policy, timeout)
+}
+
+func (ex *Executor) fetchKeptApps(namespace string) (*KeptApps, error) {
+	deployments, err := ex.lister.Deployments.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	statefulSets, err := ex.lister.StatefulSets.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	daemonSets, err := ex.lister.DaemonSets.DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeptApps(deployments, statefulSets, daemonSets), nil
+}
+
+func (ex *Executor) updateDeployment(ctx context.Context, deployment *apps.Deployment) error {
+	_, err := ex.kube.CoreClient().
+		AppsV1().
+		Deployments(deployment.Namespace).
+		Update(ctx, deployment, meta.UpdateOptions{})
+	return err
+}
+
+func (ex *Executor) updateStatefulSet(ctx context.Context, sts *apps.StatefulSet) error {
+	_, err := ex.kube.CoreClient().
+		AppsV1().
+		StatefulSets(sts.Namespace).
+		Update(ctx, sts, meta.UpdateOptions{})
+	return err
+}
+
+func (ex *Executor) updateDaemonSet(ctx context.Context, ds *apps.DaemonSet) error {
+	_, err := ex.kube.CoreClient().
+		AppsV1().
+		DaemonSets(ds.Namespace).
+		Update(ctx, ds, meta.UpdateOptions{})
+	return err
+}
+
+func (ex *Executor) updateCronJob(ctx context.Context, cj *batch.CronJob) error {
+	_, err := ex.kube.CoreClient().
+		BatchV1().
+		CronJobs(cj.Namespace).
+		Update(ctx, cj, meta.UpdateOptions{})
+	return err
+}
+
+func (ex *Executor) updateAutoscaler(ctx context.Context, hpa *autoscaling.HorizontalPodAutoscaler) error {
+	_, err := ex.kube.CoreClient().
+		AutoscalingV1().
+		HorizontalPodAutoscalers(hpa.Namespace).
+		Update(ctx, hpa, meta.UpdateOptions{})
+	return err
+}
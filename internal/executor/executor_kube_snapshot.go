@@ -0,0 +1,184 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/kubernetes"
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+)
+
+const (
+	_SnapshotPrefix = "stand-schedule-snapshot-"
+	_SnapshotKey    = "snapshot.json"
+)
+
+// saveSnapshot captures workloads state before shutdown and stores it in configMap owned by policy.
+func (ex *Executor) saveSnapshot(
+	ctx context.Context,
+	namespace string,
+	policy *apis.StandSchedulePolicy,
+) (*Snapshot, error) {
+	deployments, err := ex.lister.Deployments.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	statefulSets, err := ex.lister.StatefulSets.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	daemonSets, err := ex.lister.DaemonSets.DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	autoscalers, err := ex.lister.Autoscalers.HorizontalPodAutoscalers(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	cronJobs, err := ex.lister.CronJobs.CronJobs(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ex.getSnapshotConfigMap(ctx, namespace, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := decodeSnapshot(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := NewSnapshot(deployments, statefulSets, daemonSets, autoscalers, cronJobs).Merge(prev)
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	ex.logger.Debug("Save workloads snapshot in namespace",
+		zap.String("namespace", namespace),
+		zap.String("configmap", _SnapshotPrefix+policy.Name))
+
+	if existing != nil {
+		cm := existing.DeepCopy()
+		cm.Data = map[string]string{_SnapshotKey: string(data)}
+
+		_, err = ex.kube.CoreClient().
+			CoreV1().
+			ConfigMaps(namespace).
+			Update(ctx, cm, meta.UpdateOptions{})
+		return snapshot, err
+	}
+
+	cm := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      _SnapshotPrefix + policy.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				_PolicyLabel: policy.Name,
+			},
+			OwnerReferences: []meta.OwnerReference{
+				{
+					APIVersion: apis.GroupVersion.String(),
+					Kind:       "StandSchedulePolicy",
+					Name:       policy.Name,
+					UID:        policy.UID,
+				},
+			},
+		},
+		Data: map[string]string{_SnapshotKey: string(data)},
+	}
+
+	_, err = ex.kube.CoreClient().
+		CoreV1().
+		ConfigMaps(namespace).
+		Create(ctx, cm, meta.CreateOptions{})
+	return snapshot, err
+}
+
+// loadSnapshot returns workloads state stored on shutdown, nil if snapshot not exists.
+func (ex *Executor) loadSnapshot(
+	ctx context.Context,
+	namespace string,
+	policy *apis.StandSchedulePolicy,
+) (*Snapshot, error) {
+	cm, err := ex.getSnapshotConfigMap(ctx, namespace, policy)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshot(cm)
+}
+
+func (ex *Executor) deleteSnapshot(ctx context.Context, namespace string, policy *apis.StandSchedulePolicy) error {
+	ex.logger.Debug("Delete workloads snapshot in namespace",
+		zap.String("namespace", namespace),
+		zap.String("configmap", _SnapshotPrefix+policy.Name))
+
+	cm, err := ex.getSnapshotConfigMap(ctx, namespace, policy)
+	if err != nil || cm == nil {
+		return err
+	}
+
+	err = ex.kube.CoreClient().
+		CoreV1().
+		ConfigMaps(namespace).
+		Delete(ctx, cm.Name, meta.DeleteOptions{
+			Preconditions: &meta.Preconditions{UID: &cm.UID},
+		})
+
+	return kubernetes.IgnoreNotFound(err)
+}
+
+func (ex *Executor) getSnapshotConfigMap(
+	ctx context.Context,
+	namespace string,
+	policy *apis.StandSchedulePolicy,
+) (*core.ConfigMap, error) {
+	name := _SnapshotPrefix + policy.Name
+
+	cm, err := ex.kube.CoreClient().
+		CoreV1().
+		ConfigMaps(namespace).
+		Get(ctx, name, meta.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsOwnedByPolicy(cm.ObjectMeta, policy) {
+		return nil, fmt.Errorf("configmap %s in namespace %s is not managed by policy %s", name, namespace, policy.Name)
+	}
+
+	return cm, nil
+}
+
+func decodeSnapshot(cm *core.ConfigMap) (*Snapshot, error) {
+	if cm == nil {
+		return nil, nil
+	}
+
+	data, ok := cm.Data[_SnapshotKey]
+	if !ok {
+		return nil, nil
+	}
+
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal([]byte(data), snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot in configmap %s in namespace %s: %w", cm.Name, cm.Namespace, err)
+	}
+	return snapshot, nil
+}
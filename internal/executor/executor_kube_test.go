@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corecs "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	standscs "github.com/dodopizza/stand-schedule-policy-controller/pkg/client/clientset/versioned"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
+)
+
+type (
+	fakeKube struct {
+		core *fake.Clientset
+	}
+)
+
+func (k *fakeKube) CoreClient() corecs.Interface {
+	return k.core
+}
+
+func (k *fakeKube) StandSchedulesClient() standscs.Interface {
+	return nil
+}
+
+func newPod(name string, terminated bool, podLabels map[string]string) *core.Pod {
+	state := core.ContainerState{Running: &core.ContainerStateRunning{}}
+	if terminated {
+		state = core.ContainerState{Terminated: &core.ContainerStateTerminated{}}
+	}
+
+	return &core.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "namespace", Labels: podLabels},
+		Status: core.PodStatus{
+			ContainerStatuses: []core.ContainerStatus{{Name: "app", State: state}},
+		},
+	}
+}
+
+func newKubeExecutor(objects ...runtime.Object) (*Executor, *fake.Clientset) {
+	client := fake.NewSimpleClientset(objects...)
+	return &Executor{logger: zap.NewNop(), kube: &fakeKube{core: client}}, client
+}
+
+func Test_EvictPod(t *testing.T) {
+	budgetErr := errors.NewTooManyRequests("cannot evict pod as it would violate the pod's disruption budget", 0)
+
+	cases := []struct {
+		name         string
+		refusals     int
+		evictErr     error
+		fallback     time.Duration
+		expErr       bool
+		expEvictions int
+		expDeleted   bool
+	}{
+		{name: "evicted", fallback: time.Second, expEvictions: 1},
+		{name: "evicted after refusals by disruption budget", refusals: 2, fallback: time.Second, expEvictions: 3},
+		{name: "refused until fallback timeout", refusals: 1000, fallback: time.Millisecond * 20, expDeleted: true},
+		{name: "already deleted", evictErr: errors.NewNotFound(core.Resource("pods"), "pod"), fallback: time.Second, expEvictions: 1},
+		{name: "eviction failed", evictErr: errors.NewInternalError(fmt.Errorf("etcd unavailable")), fallback: time.Second, expErr: true, expEvictions: 1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			pod := newPod("pod", false, nil)
+			ex, client := newKubeExecutor(pod)
+
+			evictions := 0
+			client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				evictions++
+				if evictions <= tc.refusals {
+					return true, nil, budgetErr
+				}
+				return true, nil, tc.evictErr
+			})
+
+			err := ex.evictPod(context.Background(), pod, tc.fallback, time.Millisecond)
+
+			assert.Equal(t, tc.expErr, err != nil)
+			if tc.expEvictions > 0 {
+				assert.Equal(t, tc.expEvictions, evictions)
+			}
+			_, err = client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, meta.GetOptions{})
+			assert.Equal(t, tc.expDeleted, errors.IsNotFound(err))
+		})
+	}
+}
+
+func Test_WaitTerminatingPods(t *testing.T) {
+	kept := &KeptApps{selectors: []labels.Selector{labels.SelectorFromSet(labels.Set{"app": "kept"})}}
+
+	cases := []struct {
+		name        string
+		pods        []runtime.Object
+		expDegraded bool
+		expPods     []string
+	}{
+		{
+			name: "all terminated",
+			pods: []runtime.Object{newPod("first", true, nil), newPod("second", true, nil)},
+		},
+		{
+			name: "kept pods are running",
+			pods: []runtime.Object{newPod("first", true, nil), newPod("kept", false, map[string]string{"app": "kept"})},
+		},
+		{
+			name:        "pods stuck in terminating state",
+			pods:        []runtime.Object{newPod("first", false, nil), newPod("second", true, nil), newPod("third", false, nil)},
+			expDegraded: true,
+			expPods:     []string{"first", "third"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ex, _ := newKubeExecutor(tc.pods...)
+			timeouts := apis.TimeoutsSpec{
+				WaitTerminatingPods: meta.Duration{Duration: time.Millisecond * 20},
+				WaitPodsInterval:    meta.Duration{Duration: time.Millisecond},
+			}
+
+			err := ex.waitTerminatingPods(context.Background(), "namespace", timeouts, kept)
+
+			assert.Equal(t, tc.expDegraded, util.IsDegraded(err))
+			if !tc.expDegraded {
+				assert.NoError(t, err)
+			}
+			for _, pod := range tc.expPods {
+				assert.Contains(t, err.Error(), pod)
+			}
+		})
+	}
+}
+
+func Test_ForEachNamespace(t *testing.T) {
+	namespaces := []string{"first", "second", "third", "fourth"}
+
+	cases := []struct {
+		name           string
+		parallelism    int
+		failed         string
+		expParallelism int32
+		expOrdered     bool
+	}{
+		{name: "in order by default", parallelism: 0, expParallelism: 1, expOrdered: true},
+		{name: "in order", parallelism: 1, expParallelism: 1, expOrdered: true},
+		{name: "simultaneously", parallelism: 2, expParallelism: 2},
+		{name: "failed namespace does not stop others", parallelism: 2, failed: "second", expParallelism: 2},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ex, _ := newKubeExecutor()
+			policy := &apis.StandSchedulePolicy{Spec: apis.StandSchedulePolicySpec{Parallelism: tc.parallelism}}
+
+			var (
+				running   int32
+				maxActive int32
+				lock      sync.Mutex
+				processed []string
+			)
+			err := ex.forEachNamespace(policy, namespaces, func(namespace string) error {
+				active := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+
+				lock.Lock()
+				if active > maxActive {
+					maxActive = active
+				}
+				processed = append(processed, namespace)
+				lock.Unlock()
+
+				time.Sleep(time.Millisecond * 20)
+
+				if namespace == tc.failed {
+					return fmt.Errorf("namespace %s failed", namespace)
+				}
+				return nil
+			})
+
+			assert.Equal(t, tc.failed != "", err != nil)
+			assert.Equal(t, tc.expParallelism, maxActive)
+			if tc.expOrdered {
+				assert.Equal(t, namespaces, processed)
+			} else {
+				assert.ElementsMatch(t, namespaces, processed)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package kubernetes
 
 import (
 	apps "k8s.io/client-go/listers/apps/v1"
+	autoscaling "k8s.io/client-go/listers/autoscaling/v1"
+	batch "k8s.io/client-go/listers/batch/v1"
 	core "k8s.io/client-go/listers/core/v1"
 
 	stands "github.com/dodopizza/stand-schedule-policy-controller/pkg/client/listers/standschedules/v1"
@@ -12,6 +14,9 @@ type (
 		Namespaces   core.NamespaceLister
 		Deployments  apps.DeploymentLister
 		StatefulSets apps.StatefulSetLister
+		DaemonSets   apps.DaemonSetLister
+		Autoscalers  autoscaling.HorizontalPodAutoscalerLister
+		CronJobs     batch.CronJobLister
 		Stands       stands.StandSchedulePolicyLister
 	}
 )
@@ -21,6 +26,9 @@ func NewListerGroup(f *FactoryGroup) *ListerGroup {
 		Namespaces:   f.Core.Core().V1().Namespaces().Lister(),
 		Deployments:  f.Core.Apps().V1().Deployments().Lister(),
 		StatefulSets: f.Core.Apps().V1().StatefulSets().Lister(),
+		DaemonSets:   f.Core.Apps().V1().DaemonSets().Lister(),
+		Autoscalers:  f.Core.Autoscaling().V1().HorizontalPodAutoscalers().Lister(),
+		CronJobs:     f.Core.Batch().V1().CronJobs().Lister(),
 		Stands:       f.Stands.StandSchedules().V1().StandSchedulePolicies().Lister(),
 	}
 }
@@ -0,0 +1,26 @@
+package kubernetes
+
+import (
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corescheme "k8s.io/client-go/kubernetes/scheme"
+	typedcore "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	standsscheme "github.com/dodopizza/stand-schedule-policy-controller/pkg/client/clientset/versioned/scheme"
+)
+
+// NewEventRecorder creates recorder, which publishes events for core and policy objects.
+func NewEventRecorder(k Interface, component string) record.EventRecorder {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corescheme.AddToScheme(scheme))
+	utilruntime.Must(standsscheme.AddToScheme(scheme))
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcore.EventSinkImpl{
+		Interface: k.CoreClient().CoreV1().Events(""),
+	})
+
+	return broadcaster.NewRecorder(scheme, core.EventSource{Component: component})
+}
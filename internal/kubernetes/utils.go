@@ -24,7 +24,7 @@ func IgnoreError(err error, handler func(error) bool) error {
 	return err
 }
 
-func SetAnnotation(m meta.ObjectMeta, name, val string) {
+func SetAnnotation(m *meta.ObjectMeta, name, val string) {
 	if m.Annotations == nil {
 		m.Annotations = make(map[string]string)
 	}
@@ -32,9 +32,13 @@ func SetAnnotation(m meta.ObjectMeta, name, val string) {
 }
 
 func GetAnnotation(m meta.ObjectMeta, name string) (string, bool) {
-	if m.Annotations == nil {
-		m.Annotations = make(map[string]string)
-	}
 	val, ok := m.Annotations[name]
 	return val, ok
 }
+
+func SetLabel(m *meta.ObjectMeta, name, val string) {
+	if m.Labels == nil {
+		m.Labels = make(map[string]string)
+	}
+	m.Labels[name] = val
+}
@@ -115,10 +115,16 @@ func (h *Handler) WaitPolicyReady() (bool, error) {
 	}
 
 	status := policy.Status.GetScheduleStatus(h.Type)
-	statusCompleted := status.Status == string(apis.ConditionCompleted) || status.Status == string(apis.ConditionFailed)
+	statusCompleted := strings.HasPrefix(status.Status, string(apis.ConditionCompleted)) ||
+		strings.HasPrefix(status.Status, string(apis.ConditionFailed)) ||
+		strings.HasPrefix(status.Status, string(apis.ConditionDegraded)) ||
+		strings.HasPrefix(status.Status, string(apis.ConditionCancelled))
 
 	if statusCompleted {
-		fmt.Printf("\nDone\n")
+		fmt.Printf("\nDone: %s\n", status.Status)
+		if status.Message != "" {
+			fmt.Printf("%s\n", status.Message)
+		}
 	}
 
 	return statusCompleted, nil
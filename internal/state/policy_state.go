@@ -1,15 +1,21 @@
 package state
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
 )
 
 type (
 	PolicyState struct {
+		lock     sync.Mutex
 		startup  *ScheduleState
 		shutdown *ScheduleState
+		stopped  int32
+		enforced func(namespace string) bool
 	}
 )
 
@@ -30,7 +36,7 @@ func NewPolicyState(schedules *apis.SchedulesSpec) (*PolicyState, error) {
 	}, nil
 }
 
-func (ps *PolicyState) GetSchedule(st apis.ConditionScheduleType) *ScheduleState {
+func (ps *PolicyState) getSchedule(st apis.ConditionScheduleType) *ScheduleState {
 	switch st {
 	case apis.StatusStartup:
 		return ps.startup
@@ -40,7 +46,21 @@ func (ps *PolicyState) GetSchedule(st apis.ConditionScheduleType) *ScheduleState
 	return nil
 }
 
+// WithSchedule runs f with schedule of specified type under policy lock, as schedules are updated by executions
+// and read by reconciler simultaneously. Function f should not call methods of policy state.
+func (ps *PolicyState) WithSchedule(st apis.ConditionScheduleType, f func(schedule *ScheduleState)) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if schedule := ps.getSchedule(st); schedule != nil {
+		f(schedule)
+	}
+}
+
 func (ps *PolicyState) GetConditions() []apis.StatusCondition {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
 	conditions := []apis.StatusCondition{}
 	conditions = append(conditions, ps.startup.GetConditions(apis.StatusStartup)...)
 	conditions = append(conditions, ps.shutdown.GetConditions(apis.StatusShutdown)...)
@@ -48,25 +68,62 @@ func (ps *PolicyState) GetConditions() []apis.StatusCondition {
 }
 
 func (ps *PolicyState) UpdateStatus(st apis.ConditionScheduleType, at time.Time, err error) {
-	schedule := ps.GetSchedule(st)
+	if st == apis.StatusShutdown {
+		ps.SetStopped(err == nil || util.IsDegraded(err))
+	}
 
-	if err != nil {
-		schedule.SetFailed(at)
-	} else {
-		schedule.SetCompleted(at)
+	ps.WithSchedule(st, func(schedule *ScheduleState) {
+		switch {
+		case err == nil:
+			schedule.SetCompleted(at)
+		case util.IsDegraded(err):
+			schedule.SetDegraded(at)
+			schedule.SetMessage(err.Error())
+		case util.IsCancelled(err):
+			schedule.SetCancelled(at)
+			schedule.SetMessage(err.Error())
+		default:
+			schedule.SetFailed(at)
+			schedule.SetMessage(err.Error())
+		}
+	})
+}
+
+// SetStopped marks that stand is stopped by shutdown and workloads should remain scaled down.
+func (ps *PolicyState) SetStopped(stopped bool) {
+	var val int32
+	if stopped {
+		val = 1
 	}
+	atomic.StoreInt32(&ps.stopped, val)
 }
 
-func (ps *PolicyState) ScheduleEquals(other *PolicyState) bool {
-	return ps.startup.Equals(other.startup) && ps.shutdown.Equals(other.shutdown)
+// IsStopped checks that latest shutdown succeeded and startup not started yet.
+func (ps *PolicyState) IsStopped() bool {
+	return atomic.LoadInt32(&ps.stopped) == 1
 }
 
-func (ps *PolicyState) ScheduleRequired(st apis.ConditionScheduleType, at time.Time) bool {
-	switch st {
-	case apis.StatusStartup:
-		return ps.startup.ScheduleRequired(at)
-	case apis.StatusShutdown:
-		return ps.shutdown.ScheduleRequired(at)
+// SetEnforcedNamespaces caches matcher of namespaces, where shutdown is enforced, nil disables enforcement.
+func (ps *PolicyState) SetEnforcedNamespaces(matcher func(namespace string) bool) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	ps.enforced = matcher
+}
+
+// IsEnforced checks that stand is stopped and its shutdown is enforced in namespace.
+func (ps *PolicyState) IsEnforced(namespace string) bool {
+	if !ps.IsStopped() {
+		return false
 	}
-	return false
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	return ps.enforced != nil && ps.enforced(namespace)
+}
+
+// ScheduleEquals compares cron and override of schedules, they are immutable, so no lock is required.
+func (ps *PolicyState) ScheduleEquals(other *PolicyState) bool {
+	return ps.startup.Equals(other.startup) && ps.shutdown.Equals(other.shutdown)
 }
@@ -2,12 +2,14 @@ package state
 
 import (
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
+	"github.com/dodopizza/stand-schedule-policy-controller/pkg/util"
 )
 
 func Test_GetSchedule(t *testing.T) {
@@ -30,13 +32,13 @@ func Test_GetSchedule(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Equal(t, sh1, ps.GetSchedule(apis.StatusShutdown))
+	assert.Equal(t, sh1, ps.getSchedule(apis.StatusShutdown))
 
 	sh2, err := NewSchedule(apis.CronSchedule{Cron: "2 * * * *"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Equal(t, sh2, ps.GetSchedule(apis.StatusStartup))
+	assert.Equal(t, sh2, ps.getSchedule(apis.StatusStartup))
 }
 
 func Test_UpdateStatus(t *testing.T) {
@@ -54,14 +56,152 @@ func Test_UpdateStatus(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	ps.GetSchedule(apis.StatusStartup).SetFiredAfter(ts)
-	ps.GetSchedule(apis.StatusShutdown).SetFiredAfter(ts)
+	ps.getSchedule(apis.StatusStartup).SetFiredAfter(ts)
+	ps.getSchedule(apis.StatusShutdown).SetFiredAfter(ts)
 
 	ps.UpdateStatus(apis.StatusStartup, ts.Add(time.Minute*1).Add(time.Second*20), nil)
-	assert.Equal(t, ts.Add(time.Minute*1).Add(time.Second*20), ps.GetSchedule(apis.StatusStartup).completedAt)
-	assert.Equal(t, time.Time{}, ps.GetSchedule(apis.StatusStartup).failedAt)
+	assert.Equal(t, ts.Add(time.Minute*1).Add(time.Second*20), ps.getSchedule(apis.StatusStartup).completedAt)
+	assert.Equal(t, time.Time{}, ps.getSchedule(apis.StatusStartup).failedAt)
 
 	ps.UpdateStatus(apis.StatusShutdown, ts.Add(time.Minute*1).Add(time.Second*20), errors.New("some"))
-	assert.Equal(t, time.Time{}, ps.GetSchedule(apis.StatusShutdown).completedAt)
-	assert.Equal(t, ts.Add(time.Minute*1).Add(time.Second*20), ps.GetSchedule(apis.StatusShutdown).failedAt)
+	assert.Equal(t, time.Time{}, ps.getSchedule(apis.StatusShutdown).completedAt)
+	assert.Equal(t, ts.Add(time.Minute*1).Add(time.Second*20), ps.getSchedule(apis.StatusShutdown).failedAt)
+	assert.Equal(t, "some", ps.getSchedule(apis.StatusShutdown).message)
+
+	ps.UpdateStatus(apis.StatusStartup, ts.Add(time.Minute*2), util.NewDegradedError(errors.New("not ready")))
+	assert.Equal(t, time.Time{}, ps.getSchedule(apis.StatusStartup).completedAt)
+	assert.Equal(t, time.Time{}, ps.getSchedule(apis.StatusStartup).failedAt)
+	assert.Equal(t, ts.Add(time.Minute*2), ps.getSchedule(apis.StatusStartup).degradedAt)
+	assert.Equal(t, "not ready", ps.getSchedule(apis.StatusStartup).message)
+
+	ps.UpdateStatus(apis.StatusShutdown, ts.Add(time.Minute*3), util.NewCancelledError("policy deleted"))
+	assert.Equal(t, time.Time{}, ps.getSchedule(apis.StatusShutdown).failedAt)
+	assert.Equal(t, ts.Add(time.Minute*3), ps.getSchedule(apis.StatusShutdown).cancelledAt)
+	assert.Equal(t, ts.Add(time.Minute*3), ps.getSchedule(apis.StatusShutdown).GetExecutedTime())
+	assert.Equal(t, "cancelled: policy deleted", ps.getSchedule(apis.StatusShutdown).message)
+	assert.False(t, ps.IsStopped())
+}
+
+func Test_IsStopped(t *testing.T) {
+	ts := time.Now().Round(time.Minute)
+	ps, err := NewPolicyState(
+		&apis.SchedulesSpec{
+			Startup: apis.CronSchedule{
+				Cron: "* * * * *",
+			},
+			Shutdown: apis.CronSchedule{
+				Cron: "* * * * *",
+			},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, ps.IsStopped())
+
+	ps.UpdateStatus(apis.StatusShutdown, ts, errors.New("some"))
+	assert.False(t, ps.IsStopped())
+
+	ps.UpdateStatus(apis.StatusShutdown, ts, util.NewDegradedError(errors.New("terminating")))
+	assert.True(t, ps.IsStopped())
+
+	ps.UpdateStatus(apis.StatusShutdown, ts, nil)
+	assert.True(t, ps.IsStopped())
+
+	ps.SetStopped(false)
+	assert.False(t, ps.IsStopped())
+}
+
+func Test_WithSchedule(t *testing.T) {
+	ts := time.Now().Round(time.Minute)
+	ps, err := NewPolicyState(
+		&apis.SchedulesSpec{
+			Startup: apis.CronSchedule{
+				Cron: "* * * * *",
+			},
+			Shutdown: apis.CronSchedule{
+				Cron: "* * * * *",
+			},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.WithSchedule(apis.StatusShutdown, func(schedule *ScheduleState) {
+		schedule.SetFiredAfter(ts)
+	})
+
+	// executions update schedule, while reconciler reads conditions
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ps.WithSchedule(apis.StatusShutdown, func(schedule *ScheduleState) {
+				schedule.SetQueued(ts)
+			})
+			ps.UpdateStatus(apis.StatusShutdown, ts, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = ps.GetConditions()
+		}()
+	}
+	wg.Wait()
+
+	executed := time.Time{}
+	ps.WithSchedule(apis.StatusShutdown, func(schedule *ScheduleState) {
+		executed = schedule.GetExecutedTime()
+	})
+	assert.Equal(t, ts, executed)
+	assert.True(t, ps.IsStopped())
+}
+
+func Test_IsEnforced(t *testing.T) {
+	cases := []struct {
+		name        string
+		stopped     bool
+		matcher     func(namespace string) bool
+		expEnforced bool
+	}{
+		{
+			name:        "stopped and matched",
+			stopped:     true,
+			matcher:     func(namespace string) bool { return namespace == "stand" },
+			expEnforced: true,
+		},
+		{
+			name:    "stopped and not matched",
+			stopped: true,
+			matcher: func(namespace string) bool { return namespace == "other" },
+		},
+		{
+			name:    "enforcement disabled",
+			stopped: true,
+		},
+		{
+			name:    "not stopped",
+			matcher: func(namespace string) bool { return namespace == "stand" },
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ps, err := NewPolicyState(&apis.SchedulesSpec{
+				Startup:  apis.CronSchedule{Cron: "* * * * *"},
+				Shutdown: apis.CronSchedule{Cron: "* * * * *"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ps.SetStopped(tc.stopped)
+			ps.SetEnforcedNamespaces(tc.matcher)
+
+			s := New()
+			s.AddOrUpdate("policy", ps)
+
+			assert.Equal(t, tc.expEnforced, ps.IsEnforced("stand"))
+			assert.Equal(t, tc.expEnforced, len(s.GetEnforced("stand")) == 1)
+		})
+	}
 }
@@ -17,6 +17,10 @@ type (
 		fireAt      time.Time
 		completedAt time.Time
 		failedAt    time.Time
+		degradedAt  time.Time
+		cancelledAt time.Time
+		queuedAt    time.Time
+		message     string
 	}
 )
 
@@ -51,6 +55,12 @@ func (ss *ScheduleState) GetExecutedTime() time.Time {
 	if !ss.completedAt.IsZero() {
 		return ss.completedAt
 	}
+	if !ss.degradedAt.IsZero() {
+		return ss.degradedAt
+	}
+	if !ss.cancelledAt.IsZero() {
+		return ss.cancelledAt
+	}
 	return ss.failedAt
 }
 
@@ -81,6 +91,14 @@ func (ss *ScheduleState) GetConditions(st apis.ConditionScheduleType) []apis.Sta
 		})
 	}
 
+	if !ss.queuedAt.IsZero() {
+		conditions = append(conditions, apis.StatusCondition{
+			Type:               apis.ConditionQueued,
+			Status:             st,
+			LastTransitionTime: meta.NewTime(ss.queuedAt),
+		})
+	}
+
 	if !ss.completedAt.IsZero() {
 		conditions = append(conditions, apis.StatusCondition{
 			Type:               apis.ConditionCompleted,
@@ -94,6 +112,25 @@ func (ss *ScheduleState) GetConditions(st apis.ConditionScheduleType) []apis.Sta
 			Type:               apis.ConditionFailed,
 			Status:             st,
 			LastTransitionTime: meta.NewTime(ss.failedAt),
+			Message:            ss.message,
+		})
+	}
+
+	if !ss.degradedAt.IsZero() {
+		conditions = append(conditions, apis.StatusCondition{
+			Type:               apis.ConditionDegraded,
+			Status:             st,
+			LastTransitionTime: meta.NewTime(ss.degradedAt),
+			Message:            ss.message,
+		})
+	}
+
+	if !ss.cancelledAt.IsZero() {
+		conditions = append(conditions, apis.StatusCondition{
+			Type:               apis.ConditionCancelled,
+			Status:             st,
+			LastTransitionTime: meta.NewTime(ss.cancelledAt),
+			Message:            ss.message,
 		})
 	}
 
@@ -104,16 +141,71 @@ func (ss *ScheduleState) SetFiredAfter(ts time.Time) {
 	ss.fireAt = ss.GetNextExecutionTime(ts)
 	ss.failedAt = time.Time{}
 	ss.completedAt = time.Time{}
+	ss.degradedAt = time.Time{}
+	ss.cancelledAt = time.Time{}
+	ss.queuedAt = time.Time{}
+	ss.message = ""
 }
 
 func (ss *ScheduleState) SetCompleted(at time.Time) {
 	ss.completedAt = at
 	ss.failedAt = time.Time{}
+	ss.degradedAt = time.Time{}
+	ss.cancelledAt = time.Time{}
+	ss.queuedAt = time.Time{}
+	ss.message = ""
 }
 
 func (ss *ScheduleState) SetFailed(at time.Time) {
 	ss.failedAt = at
 	ss.completedAt = time.Time{}
+	ss.degradedAt = time.Time{}
+	ss.cancelledAt = time.Time{}
+	ss.queuedAt = time.Time{}
+	ss.message = ""
+}
+
+func (ss *ScheduleState) SetDegraded(at time.Time) {
+	ss.degradedAt = at
+	ss.completedAt = time.Time{}
+	ss.failedAt = time.Time{}
+	ss.cancelledAt = time.Time{}
+	ss.queuedAt = time.Time{}
+	ss.message = ""
+}
+
+func (ss *ScheduleState) SetCancelled(at time.Time) {
+	ss.cancelledAt = at
+	ss.completedAt = time.Time{}
+	ss.failedAt = time.Time{}
+	ss.degradedAt = time.Time{}
+	ss.queuedAt = time.Time{}
+	ss.message = ""
+}
+
+// SetQueued marks that execution is postponed until controller has free execution slot.
+func (ss *ScheduleState) SetQueued(at time.Time) {
+	if ss.queuedAt.IsZero() {
+		ss.queuedAt = at
+	}
+}
+
+func (ss *ScheduleState) IsQueued() bool {
+	return !ss.queuedAt.IsZero()
+}
+
+// SetDequeued marks that postponed execution is started.
+func (ss *ScheduleState) SetDequeued() {
+	ss.queuedAt = time.Time{}
+}
+
+// IsOverride checks that schedule fires at override time instead of cron.
+func (ss *ScheduleState) IsOverride() bool {
+	return !ss.override.IsZero() && ss.fireAt.Equal(ss.override)
+}
+
+func (ss *ScheduleState) SetMessage(message string) {
+	ss.message = message
 }
 
 func (ss *ScheduleState) ScheduleRequired(current time.Time) bool {
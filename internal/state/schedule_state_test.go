@@ -121,6 +121,38 @@ func Test_GetConditions(t *testing.T) {
 	}, schedule.GetConditions(apis.StatusShutdown))
 }
 
+func Test_SetQueued(t *testing.T) {
+	ts := time.Now().Round(time.Minute)
+	schedule, err := NewSchedule(apis.CronSchedule{Cron: "* * * * *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule.SetFiredAfter(ts)
+	schedule.SetQueued(ts.Add(time.Minute))
+	schedule.SetQueued(ts.Add(time.Minute * 2))
+	assert.True(t, schedule.IsQueued())
+	assert.Equal(t, []apis.StatusCondition{
+		{
+			Type:               apis.ConditionScheduled,
+			Status:             apis.StatusStartup,
+			LastTransitionTime: meta.NewTime(ts.Add(time.Minute)),
+		},
+		{
+			Type:               apis.ConditionQueued,
+			Status:             apis.StatusStartup,
+			LastTransitionTime: meta.NewTime(ts.Add(time.Minute)),
+		},
+	}, schedule.GetConditions(apis.StatusStartup))
+
+	schedule.SetDequeued()
+	assert.False(t, schedule.IsQueued())
+
+	schedule.SetQueued(ts.Add(time.Minute * 3))
+	schedule.SetCompleted(ts.Add(time.Minute * 10))
+	assert.False(t, schedule.IsQueued())
+}
+
 func Test_ScheduleRequiredCron(t *testing.T) {
 	ts := time.Now().Round(time.Minute)
 	schedule, err := NewSchedule(apis.CronSchedule{Cron: "* * * * *"})
@@ -38,3 +38,17 @@ func (s *State) Delete(key string) {
 
 	delete(s.data, key)
 }
+
+// GetEnforced returns names of stopped policies, which enforce shutdown in namespace.
+func (s *State) GetEnforced(namespace string) []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var names []string
+	for name, ps := range s.data {
+		if ps.IsEnforced(namespace) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
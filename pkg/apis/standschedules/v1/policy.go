@@ -7,6 +7,11 @@ Authored by The Infrastructure Platform Team.
 package v1
 
 import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -58,6 +63,53 @@ type StandSchedulePolicySpec struct {
 	// Resources contains external resources spec.
 	// +optional
 	Resources ResourcesSpec `json:"resources,omitempty"`
+
+	// Eviction contains pods eviction spec used on shutdown.
+	// +optional
+	Eviction EvictionSpec `json:"eviction,omitempty"`
+
+	// Readiness contains workloads readiness spec used on startup.
+	// +optional
+	Readiness ReadinessSpec `json:"readiness,omitempty"`
+
+	// StartupOrder defines regex filters for deployments and statefulsets names to start them in waves.
+	// Workloads matched by first filter are started in first wave after not matched ones, and so on.
+	// Shutdown performed in reverse order. Startup order annotation on workload takes precedence.
+	// Policy with invalid filter is not scheduled.
+	// +optional
+	StartupOrder []string `json:"startupOrder,omitempty"`
+
+	// Jitter defines max delay added to scheduled startup and shutdown, to spread policies with the same schedule.
+	// Delay is derived from policy name, so it is the same for every execution. Overrides are not delayed.
+	// +optional
+	Jitter metav1.Duration `json:"jitter,omitempty"`
+
+	// Parallelism defines how many namespaces are processed simultaneously.
+	// Namespaces are processed one by one in order, when not specified or set to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Parallelism int `json:"parallelism,omitempty"`
+
+	// Quota contains resource quota spec used to prevent pods creation on shutdown.
+	// +optional
+	Quota QuotaSpec `json:"quota,omitempty"`
+
+	// EnforceShutdown enables scale down of deployments and statefulsets changed while stand is stopped.
+	// +optional
+	EnforceShutdown bool `json:"enforceShutdown,omitempty"`
+
+	// Suspend skips scheduled startups and shutdowns of policy, until it is resumed.
+	// In-flight execution is cancelled, when policy is suspended.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Finalizer contains spec of action performed on policy deletion.
+	// +optional
+	Finalizer FinalizerSpec `json:"finalizer,omitempty"`
+
+	// Timeouts contains execution timeouts, controller defaults are used for not specified ones.
+	// +optional
+	Timeouts TimeoutsSpec `json:"timeouts,omitempty"`
 }
 
 // SchedulesSpec defines supported schedules for policy.
@@ -79,6 +131,162 @@ type CronSchedule struct {
 	Override string `json:"override,omitempty"`
 }
 
+// EvictionSpec defines how existing pods are removed from namespaces on shutdown.
+type EvictionSpec struct {
+	// Enabled switches pods removal from collection deletion to Eviction API (respecting PodDisruptionBudgets).
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Concurrency defines how many pods are evicted simultaneously.
+	// +optional
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// FallbackTimeout defines how long refused evictions are retried before pod will be deleted.
+	// +optional
+	FallbackTimeout metav1.Duration `json:"fallbackTimeout,omitempty"`
+}
+
+// ReadinessSpec defines how startup awaits workloads to become ready.
+type ReadinessSpec struct {
+	// Enabled switches startup completion to wait until deployments and statefulsets reach desired ready replicas.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Timeout defines how long workloads readiness awaited before startup will be marked as degraded.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// QuotaSpec defines resource quota created in namespaces on shutdown.
+type QuotaSpec struct {
+	// Name defines resource quota name, zero-quota by default.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Labels defines additional labels for resource quota.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Hard defines additional hard limits for resource quota, pods limit is always managed by controller.
+	// +optional
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+}
+
+// FinalizerSpec defines action performed before policy deletion, e.g. to bring stopped stand back.
+type FinalizerSpec struct {
+	// Enabled adds finalizer to policy, which is released after action performed or timeout passed.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Action defines action performed on policy deletion, Startup by default.
+	// +kubebuilder:validation:Enum=Startup;Shutdown
+	// +optional
+	Action ConditionScheduleType `json:"action,omitempty"`
+
+	// Timeout defines how long action retried before finalizer will be released.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// TimeoutsSpec defines timeouts of schedule execution and its steps.
+type TimeoutsSpec struct {
+	// Execution defines timeout of whole startup or shutdown execution.
+	// +optional
+	Execution metav1.Duration `json:"execution,omitempty"`
+
+	// Deadline defines time after schedule fire time, after which execution (or its retry) is skipped.
+	// +optional
+	Deadline metav1.Duration `json:"deadline,omitempty"`
+
+	// WaitStatefulSetPods defines how long pending pods of started statefulsets awaited.
+	// +optional
+	WaitStatefulSetPods metav1.Duration `json:"waitStatefulSetPods,omitempty"`
+
+	// WaitDeploymentPods defines how long pending pods of started deployments awaited.
+	// +optional
+	WaitDeploymentPods metav1.Duration `json:"waitDeploymentPods,omitempty"`
+
+	// WaitTerminatingPods defines how long terminating pods awaited on shutdown.
+	// +optional
+	WaitTerminatingPods metav1.Duration `json:"waitTerminatingPods,omitempty"`
+
+	// WaitPodsInterval defines interval of pods and workloads state polling.
+	// +optional
+	WaitPodsInterval metav1.Duration `json:"waitPodsInterval,omitempty"`
+
+	// WaitWave defines how long workloads of startup order wave awaited before next wave,
+	// until ready on startup and until scaled down on shutdown.
+	// +optional
+	WaitWave metav1.Duration `json:"waitWave,omitempty"`
+}
+
+// WithDefaults returns timeouts, where not specified ones are taken from defaults.
+func (in TimeoutsSpec) WithDefaults(defaults TimeoutsSpec) TimeoutsSpec {
+	merge := func(val, def metav1.Duration) metav1.Duration {
+		if val.Duration <= 0 {
+			return def
+		}
+		return val
+	}
+
+	return TimeoutsSpec{
+		Execution:           merge(in.Execution, defaults.Execution),
+		Deadline:            merge(in.Deadline, defaults.Deadline),
+		WaitStatefulSetPods: merge(in.WaitStatefulSetPods, defaults.WaitStatefulSetPods),
+		WaitDeploymentPods:  merge(in.WaitDeploymentPods, defaults.WaitDeploymentPods),
+		WaitTerminatingPods: merge(in.WaitTerminatingPods, defaults.WaitTerminatingPods),
+		WaitPodsInterval:    merge(in.WaitPodsInterval, defaults.WaitPodsInterval),
+		WaitWave:            merge(in.WaitWave, defaults.WaitWave),
+	}
+}
+
+// Validate checks that specified timeouts are not negative and relationships between timeouts,
+// not specified ones are taken from defaults.
+func (in TimeoutsSpec) Validate(defaults TimeoutsSpec) error {
+	specified := []struct {
+		name    string
+		timeout metav1.Duration
+	}{
+		{name: "execution", timeout: in.Execution},
+		{name: "deadline", timeout: in.Deadline},
+		{name: "waitStatefulSetPods", timeout: in.WaitStatefulSetPods},
+		{name: "waitDeploymentPods", timeout: in.WaitDeploymentPods},
+		{name: "waitTerminatingPods", timeout: in.WaitTerminatingPods},
+		{name: "waitPodsInterval", timeout: in.WaitPodsInterval},
+		{name: "waitWave", timeout: in.WaitWave},
+	}
+	for _, t := range specified {
+		if t.timeout.Duration < 0 {
+			return fmt.Errorf("%s %s is negative", t.name, t.timeout)
+		}
+	}
+
+	in = in.WithDefaults(defaults)
+	if in.Deadline.Duration < in.Execution.Duration {
+		return fmt.Errorf("deadline %s is less than execution timeout %s", in.Deadline, in.Execution)
+	}
+
+	waits := []struct {
+		name    string
+		timeout metav1.Duration
+	}{
+		{name: "waitStatefulSetPods", timeout: in.WaitStatefulSetPods},
+		{name: "waitDeploymentPods", timeout: in.WaitDeploymentPods},
+		{name: "waitTerminatingPods", timeout: in.WaitTerminatingPods},
+		{name: "waitWave", timeout: in.WaitWave},
+	}
+	for _, w := range waits {
+		if w.timeout.Duration > in.Execution.Duration {
+			return fmt.Errorf("%s %s is greater than execution timeout %s", w.name, w.timeout, in.Execution)
+		}
+		if w.timeout.Duration < in.WaitPodsInterval.Duration {
+			return fmt.Errorf("%s %s is less than wait pods interval %s", w.name, w.timeout, in.WaitPodsInterval)
+		}
+	}
+
+	return nil
+}
+
 func (in *StandSchedulePolicySpec) GetSchedule(st ConditionScheduleType) *CronSchedule {
 	switch st {
 	case StatusStartup:
@@ -88,3 +296,14 @@ func (in *StandSchedulePolicySpec) GetSchedule(st ConditionScheduleType) *CronSc
 	}
 	return nil
 }
+
+// GetJitter returns deterministic delay of policy executions, it is in range [0, spec.jitter).
+func (in *StandSchedulePolicy) GetJitter() time.Duration {
+	if in.Spec.Jitter.Duration <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(in.Name))
+	return time.Duration(h.Sum64() % uint64(in.Spec.Jitter.Duration))
+}
@@ -6,31 +6,199 @@ Authored by The Infrastructure Platform Team.
 
 package v1
 
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 type AzureResourceType string
 type AzureResourceList []AzureResource
+type AzureTagOperator string
 
 const (
-	AzureResourceManagedMySQL   AzureResourceType = "mysql"
-	AzureResourceVirtualMachine AzureResourceType = "vm"
+	AzureResourceManagedMySQL       AzureResourceType = "mysql"
+	AzureResourceMySQLFlexible      AzureResourceType = "mysql-flexible"
+	AzureResourcePostgreSQLFlexible AzureResourceType = "postgresql-flexible"
+	AzureResourceVirtualMachine     AzureResourceType = "vm"
+	AzureResourceVMScaleSet         AzureResourceType = "vmss"
+	AzureResourceAKSNodePool        AzureResourceType = "aksnodepool"
+	AzureResourceAppService         AzureResourceType = "appservice"
+	AzureResourceFunctionApp        AzureResourceType = "functionapp"
+	AzureResourceContainerApp       AzureResourceType = "containerapp"
+)
+
+const (
+	AzureTagOpIn           AzureTagOperator = "In"
+	AzureTagOpNotIn        AzureTagOperator = "NotIn"
+	AzureTagOpExists       AzureTagOperator = "Exists"
+	AzureTagOpDoesNotExist AzureTagOperator = "DoesNotExist"
 )
 
 type ResourcesSpec struct {
 	// Azure contains an array of related azure resources.
 	Azure AzureResourceList `json:"azure,omitempty"`
+
+	// AzureCredentials references secret with service principal (tenantId, clientId, clientSecret)
+	// or workload identity (tenantId, clientId) credentials, controller ones are used when not specified.
+	// Secret must be in credentials namespace of controller, which is used when namespace is not specified.
+	// +optional
+	AzureCredentials *corev1.SecretReference `json:"azureCredentials,omitempty"`
 }
 
 type AzureResource struct {
 	// Type defines one of supported azure resource types.
 	Type AzureResourceType `json:"type"`
 
+	// SubscriptionId defines subscription of resource, controller one is used when not specified.
+	// +optional
+	SubscriptionId string `json:"subscriptionId,omitempty"`
+
 	// ResourceGroupName defines resource group name for resource.
-	ResourceGroupName string `json:"resourceGroupName"`
+	// Resources are listed in whole subscription when not specified, tag selector with matchTags, In or Exists
+	// terms is required then.
+	// +optional
+	ResourceGroupName string `json:"resourceGroupName,omitempty"`
+
+	// ResourceNameFilter defines regex filter for resource, all names are matched when not specified.
+	// +optional
+	ResourceNameFilter string `json:"resourceNameFilter,omitempty"`
 
-	// ResourceNameFilter defines regex filter for resource.
-	ResourceNameFilter string `json:"resourceNameFilter"`
+	// TagSelector defines tags resource must have to be matched, in addition to name filter.
+	// +optional
+	TagSelector *AzureTagSelector `json:"tagSelector,omitempty"`
 
 	// Priority specifies order in which resources will be started or shutdowned.
 	Priority int64 `json:"priority"`
+
+	// LeadTime defines how long before startup schedule resource is started, e.g. to boot databases before apps.
+	// Resources with the same lead time are started together, apart from main startup.
+	// +optional
+	LeadTime metav1.Duration `json:"leadTime,omitempty"`
+
+	// Wait enables awaiting completion of resources shutdown, so next priority is shut down after them.
+	// Startup of resources is always awaited.
+	// +optional
+	Wait bool `json:"wait,omitempty"`
+
+	// WaitTimeout defines how long completion of resources startup or shutdown is awaited,
+	// default timeout of resource type is used when not specified.
+	// +optional
+	WaitTimeout metav1.Duration `json:"waitTimeout,omitempty"`
+}
+
+// AzureTagSelector defines match of resource tags, all specified conditions must be satisfied.
+type AzureTagSelector struct {
+	// MatchTags defines tags with exact values.
+	// +optional
+	MatchTags map[string]string `json:"matchTags,omitempty"`
+
+	// MatchExpressions defines tags requirements, e.g. tag existence.
+	// +optional
+	MatchExpressions []AzureTagRequirement `json:"matchExpressions,omitempty"`
+}
+
+// AzureTagRequirement defines requirement for resource tag.
+type AzureTagRequirement struct {
+	// Key defines tag name.
+	Key string `json:"key"`
+
+	// Operator defines relationship of tag and values.
+	// +kubebuilder:validation:Enum=In;NotIn;Exists;DoesNotExist
+	Operator AzureTagOperator `json:"operator"`
+
+	// Values defines tag values for In and NotIn operators.
+	// +optional
+	Values []string `json:"values,omitempty"`
+}
+
+// Validate checks that resources are scoped by resource group or tags, which resources must have.
+func (l AzureResourceList) Validate() error {
+	for _, r := range l {
+		if r.ResourceGroupName == "" && !r.TagSelector.IsPositive() {
+			return fmt.Errorf("azure resource %s has neither resource group nor tag selector with matchTags, In or Exists", r.Type)
+		}
+		if err := r.TagSelector.Validate(); err != nil {
+			return fmt.Errorf("azure resource %s has invalid tag selector: %w", r.Type, err)
+		}
+	}
+	return nil
+}
+
+// IsPositive returns true, when selector requires resources to have some tag,
+// so it does not match untagged resources of whole subscription.
+func (in *AzureTagSelector) IsPositive() bool {
+	if in == nil {
+		return false
+	}
+	if len(in.MatchTags) > 0 {
+		return true
+	}
+	for _, req := range in.MatchExpressions {
+		if req.Operator == AzureTagOpIn || req.Operator == AzureTagOpExists {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that requirements have values required by operators.
+func (in *AzureTagSelector) Validate() error {
+	if in == nil {
+		return nil
+	}
+	for _, req := range in.MatchExpressions {
+		switch req.Operator {
+		case AzureTagOpIn, AzureTagOpNotIn:
+			if len(req.Values) == 0 {
+				return fmt.Errorf("requirement of tag %s with operator %s has no values", req.Key, req.Operator)
+			}
+		case AzureTagOpExists, AzureTagOpDoesNotExist:
+			if len(req.Values) != 0 {
+				return fmt.Errorf("requirement of tag %s with operator %s has values", req.Key, req.Operator)
+			}
+		default:
+			return fmt.Errorf("requirement of tag %s has unsupported operator %s", req.Key, req.Operator)
+		}
+	}
+	return nil
+}
+
+// Matches returns true, when tags satisfy all conditions of selector, nil selector matches any tags.
+func (in *AzureTagSelector) Matches(tags map[string]string) bool {
+	if in == nil {
+		return true
+	}
+	for key, value := range in.MatchTags {
+		if actual, ok := tags[key]; !ok || actual != value {
+			return false
+		}
+	}
+	for _, req := range in.MatchExpressions {
+		if !req.Matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns true, when tags satisfy requirement.
+func (in *AzureTagRequirement) Matches(tags map[string]string) bool {
+	value, exists := tags[in.Key]
+	switch in.Operator {
+	case AzureTagOpIn:
+		return exists && contains(in.Values, value)
+	case AzureTagOpNotIn:
+		return !exists || !contains(in.Values, value)
+	case AzureTagOpExists:
+		return exists
+	case AzureTagOpDoesNotExist:
+		return !exists
+	}
+	return false
 }
 
 func (l AzureResourceList) Len() int {
@@ -44,3 +212,37 @@ func (l AzureResourceList) Less(i, j int) bool {
 func (l AzureResourceList) Swap(i, j int) {
 	l[i], l[j] = l[j], l[i]
 }
+
+// GetLeadTimes returns distinct lead times of resources, ordered from the longest one.
+func (l AzureResourceList) GetLeadTimes() (ret []time.Duration) {
+	seen := map[time.Duration]bool{}
+	for _, r := range l {
+		if r.LeadTime.Duration > 0 && !seen[r.LeadTime.Duration] {
+			seen[r.LeadTime.Duration] = true
+			ret = append(ret, r.LeadTime.Duration)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i] > ret[j]
+	})
+	return ret
+}
+
+// WithLeadTime returns resources started with specified lead time, zero returns resources started by main startup.
+func (l AzureResourceList) WithLeadTime(leadTime time.Duration) (ret AzureResourceList) {
+	for _, r := range l {
+		if r.LeadTime.Duration == leadTime || (leadTime <= 0 && r.LeadTime.Duration <= 0) {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_AzureResourceListLeadTime(t *testing.T) {
+	lead := func(d time.Duration) metav1.Duration {
+		return metav1.Duration{Duration: d}
+	}
+	resources := AzureResourceList{
+		{Type: AzureResourceVirtualMachine, ResourceNameFilter: "app", Priority: 1},
+		{Type: AzureResourceManagedMySQL, ResourceNameFilter: "db", Priority: 0, LeadTime: lead(time.Minute * 10)},
+		{Type: AzureResourceVirtualMachine, ResourceNameFilter: "cache", Priority: 0, LeadTime: lead(time.Minute * 5)},
+		{Type: AzureResourceVirtualMachine, ResourceNameFilter: "queue", Priority: 1, LeadTime: lead(time.Minute * 10)},
+	}
+	cases := []struct {
+		name     string
+		leadTime time.Duration
+		expNames []string
+	}{
+		{
+			name:     "main startup",
+			leadTime: 0,
+			expNames: []string{"app"},
+		},
+		{
+			name:     "longest lead time",
+			leadTime: time.Minute * 10,
+			expNames: []string{"db", "queue"},
+		},
+		{
+			name:     "shortest lead time",
+			leadTime: time.Minute * 5,
+			expNames: []string{"cache"},
+		},
+		{
+			name:     "unknown lead time",
+			leadTime: time.Minute,
+			expNames: nil,
+		},
+	}
+
+	assert.Equal(t, []time.Duration{time.Minute * 10, time.Minute * 5}, resources.GetLeadTimes())
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			var names []string
+			for _, r := range resources.WithLeadTime(tc.leadTime) {
+				names = append(names, r.ResourceNameFilter)
+			}
+
+			assert.Equal(t, tc.expNames, names)
+		})
+	}
+}
+
+func Test_AzureTagSelectorMatches(t *testing.T) {
+	tags := map[string]string{"stand": "dev", "team": "infra"}
+	cases := []struct {
+		name     string
+		selector *AzureTagSelector
+		expMatch bool
+	}{
+		{
+			name:     "nil selector",
+			selector: nil,
+			expMatch: true,
+		},
+		{
+			name:     "match tags",
+			selector: &AzureTagSelector{MatchTags: map[string]string{"stand": "dev", "team": "infra"}},
+			expMatch: true,
+		},
+		{
+			name:     "match tags with other value",
+			selector: &AzureTagSelector{MatchTags: map[string]string{"stand": "qa"}},
+			expMatch: false,
+		},
+		{
+			name: "exists",
+			selector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+				{Key: "team", Operator: AzureTagOpExists},
+			}},
+			expMatch: true,
+		},
+		{
+			name: "does not exist",
+			selector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+				{Key: "team", Operator: AzureTagOpDoesNotExist},
+			}},
+			expMatch: false,
+		},
+		{
+			name: "in",
+			selector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+				{Key: "stand", Operator: AzureTagOpIn, Values: []string{"qa", "dev"}},
+			}},
+			expMatch: true,
+		},
+		{
+			name: "not in with missing tag",
+			selector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+				{Key: "owner", Operator: AzureTagOpNotIn, Values: []string{"dev"}},
+			}},
+			expMatch: true,
+		},
+		{
+			name: "all conditions required",
+			selector: &AzureTagSelector{
+				MatchTags: map[string]string{"stand": "dev"},
+				MatchExpressions: []AzureTagRequirement{
+					{Key: "team", Operator: AzureTagOpNotIn, Values: []string{"infra"}},
+				},
+			},
+			expMatch: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expMatch, tc.selector.Matches(tags))
+		})
+	}
+}
+
+func Test_AzureResourceListValidate(t *testing.T) {
+	cases := []struct {
+		name      string
+		resources AzureResourceList
+		expError  bool
+	}{
+		{
+			name:      "resource group",
+			resources: AzureResourceList{{Type: AzureResourceVirtualMachine, ResourceGroupName: "rg"}},
+			expError:  false,
+		},
+		{
+			name: "tag selector without resource group",
+			resources: AzureResourceList{{
+				Type:        AzureResourceVirtualMachine,
+				TagSelector: &AzureTagSelector{MatchTags: map[string]string{"stand": "dev"}},
+			}},
+			expError: false,
+		},
+		{
+			name:      "neither resource group nor tag selector",
+			resources: AzureResourceList{{Type: AzureResourceVirtualMachine, TagSelector: &AzureTagSelector{}}},
+			expError:  true,
+		},
+		{
+			name: "only negative terms without resource group",
+			resources: AzureResourceList{{
+				Type: AzureResourceVirtualMachine,
+				TagSelector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+					{Key: "stand", Operator: AzureTagOpNotIn, Values: []string{"prod"}},
+					{Key: "keep", Operator: AzureTagOpDoesNotExist},
+				}},
+			}},
+			expError: true,
+		},
+		{
+			name: "positive term without resource group",
+			resources: AzureResourceList{{
+				Type: AzureResourceVirtualMachine,
+				TagSelector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+					{Key: "stand", Operator: AzureTagOpExists},
+					{Key: "keep", Operator: AzureTagOpDoesNotExist},
+				}},
+			}},
+			expError: false,
+		},
+		{
+			name: "only negative terms with resource group",
+			resources: AzureResourceList{{
+				Type:              AzureResourceVirtualMachine,
+				ResourceGroupName: "rg",
+				TagSelector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+					{Key: "keep", Operator: AzureTagOpDoesNotExist},
+				}},
+			}},
+			expError: false,
+		},
+		{
+			name: "exists with values",
+			resources: AzureResourceList{{
+				Type: AzureResourceVirtualMachine,
+				TagSelector: &AzureTagSelector{MatchExpressions: []AzureTagRequirement{
+					{Key: "stand", Operator: AzureTagOpExists, Values: []string{"dev"}},
+				}},
+			}},
+			expError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.resources.Validate()
+
+			assert.Equal(t, tc.expError, err != nil)
+		})
+	}
+}
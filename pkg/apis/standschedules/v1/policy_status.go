@@ -15,6 +15,7 @@ import (
 
 type ConditionType string
 type ConditionScheduleType string
+type AzureResourceOutcome string
 
 const (
 	// ConditionScheduled means that policy actions are in progress.
@@ -23,6 +24,14 @@ const (
 	ConditionCompleted ConditionType = "Completed"
 	// ConditionFailed means that policy actions completed and failed.
 	ConditionFailed ConditionType = "Failed"
+	// ConditionDegraded means that policy actions completed, but target state not fully reached.
+	ConditionDegraded ConditionType = "Degraded"
+	// ConditionCancelled means that policy actions interrupted by conflicting event.
+	ConditionCancelled ConditionType = "Cancelled"
+	// ConditionQueued means that policy actions are waiting for free execution slot of controller.
+	ConditionQueued ConditionType = "Queued"
+	// ConditionProviderDisabled means that policy references resources of provider disabled in controller.
+	ConditionProviderDisabled ConditionType = "ProviderDisabled"
 )
 
 const (
@@ -32,6 +41,19 @@ const (
 	StatusShutdown ConditionScheduleType = "Shutdown"
 )
 
+const (
+	// AzureOutcomeSkipped means that resource was already in target state.
+	AzureOutcomeSkipped AzureResourceOutcome = "Skipped"
+	// AzureOutcomeRequested means that operation was requested, but its completion not awaited.
+	AzureOutcomeRequested AzureResourceOutcome = "Requested"
+	// AzureOutcomeCompleted means that operation completed successfully.
+	AzureOutcomeCompleted AzureResourceOutcome = "Completed"
+	// AzureOutcomeFailed means that operation failed.
+	AzureOutcomeFailed AzureResourceOutcome = "Failed"
+	// AzureOutcomeTimedOut means that operation not completed in wait timeout.
+	AzureOutcomeTimedOut AzureResourceOutcome = "TimedOut"
+)
+
 // StandSchedulePolicyStatus is a status for StandSchedulePolicy resource.
 type StandSchedulePolicyStatus struct {
 	// Conditions defines current service state of policy.
@@ -40,6 +62,82 @@ type StandSchedulePolicyStatus struct {
 	Startup ScheduleStatus `json:"startup,omitempty"`
 	// Shutdown defines status of shutdown schedule
 	Shutdown ScheduleStatus `json:"shutdown,omitempty"`
+	// Checkpoint defines progress of latest execution
+	// +optional
+	Checkpoint *ExecutionCheckpoint `json:"checkpoint,omitempty"`
+	// NodePools defines scale of AKS node pools (by resource id) before shutdown, restored on startup
+	// +optional
+	NodePools map[string]NodePoolScale `json:"nodePools,omitempty"`
+	// ContainerApps defines replicas of container apps (by resource id) before shutdown, restored on startup
+	// +optional
+	ContainerApps map[string]ContainerAppScale `json:"containerApps,omitempty"`
+	// AzureResources defines state of azure resources (by resource id) observed by latest execution,
+	// resources no longer selected by policy are removed
+	// +optional
+	AzureResources map[string]AzureResourceStatus `json:"azureResources,omitempty"`
+}
+
+// AzureResourceStatus contains state of azure resource and outcome of latest operation on it.
+type AzureResourceStatus struct {
+	// PowerState defines power state of resource: Running, Starting, Stopped, Stopping, Allocated or Unknown.
+	PowerState string `json:"powerState"`
+	// Operation defines latest operation on resource.
+	// +optional
+	Operation ConditionScheduleType `json:"operation,omitempty"`
+	// Outcome defines outcome of latest operation.
+	// +optional
+	Outcome AzureResourceOutcome `json:"outcome,omitempty"`
+	// Message contains error of failed or timed out operation.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// NodePoolScale contains AKS node pool scale settings.
+type NodePoolScale struct {
+	// Count defines nodes count.
+	Count int32 `json:"count"`
+	// EnableAutoScaling defines whether cluster autoscaler enabled for node pool.
+	// +optional
+	EnableAutoScaling bool `json:"enableAutoScaling,omitempty"`
+	// MinCount defines min nodes count of autoscaler.
+	// +optional
+	MinCount int32 `json:"minCount,omitempty"`
+	// MaxCount defines max nodes count of autoscaler.
+	// +optional
+	MaxCount int32 `json:"maxCount,omitempty"`
+}
+
+// ContainerAppScale contains container app replicas settings.
+type ContainerAppScale struct {
+	// MinReplicas defines min replicas count.
+	MinReplicas int32 `json:"minReplicas"`
+}
+
+// ExecutionCheckpoint contains progress of execution, used to resume it after restart or retry.
+type ExecutionCheckpoint struct {
+	// Type defines executed action.
+	Type ConditionScheduleType `json:"type"`
+	// FireTime defines schedule time of execution.
+	FireTime metav1.Time `json:"fireTime"`
+	// CompletedSteps defines steps (namespaces and azure priority groups) completed by execution.
+	// +optional
+	CompletedSteps []string `json:"completedSteps,omitempty"`
+	// Completed defines whether whole execution completed.
+	// +optional
+	Completed bool `json:"completed,omitempty"`
+	// Cancelled defines whether execution cancelled, e.g. superseded by opposite one, so it is not resumed.
+	// +optional
+	Cancelled bool `json:"cancelled,omitempty"`
+}
+
+// HasStep checks that step was completed by execution.
+func (in *ExecutionCheckpoint) HasStep(step string) bool {
+	for _, s := range in.CompletedSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
 }
 
 type StatusCondition struct {
@@ -62,6 +160,8 @@ type StatusCondition struct {
 type ScheduleStatus struct {
 	// Status defines how schedule finished
 	Status string `json:"status,omitempty"`
+	// Message defines details about how schedule finished
+	Message string `json:"message,omitempty"`
 }
 
 func (in *StandSchedulePolicyStatus) GetScheduleStatus(st ConditionScheduleType) *ScheduleStatus {
@@ -88,19 +188,27 @@ func (in *ScheduleStatus) UpdateFromConditions(st ConditionScheduleType, conditi
 	in.Status = "Disabled"
 
 	for _, condition := range conditions {
-		if condition.Status != st {
+		// provider conditions don't describe execution of schedule
+		if condition.Status != st || condition.Type == ConditionProviderDisabled {
 			continue
 		}
 
 		t := condition.LastTransitionTime.Format(time.RFC3339)
+		in.Message = condition.Message
 
 		switch condition.Type {
 		case ConditionScheduled:
 			in.Status = fmt.Sprintf("Scheduled at %s", t)
+		case ConditionQueued:
+			in.Status = fmt.Sprintf("Queued at %s", t)
 		case ConditionFailed:
 			in.Status = fmt.Sprintf("Failed at %s", t)
 		case ConditionCompleted:
 			in.Status = fmt.Sprintf("Completed at %s", t)
+		case ConditionDegraded:
+			in.Status = fmt.Sprintf("Degraded at %s", t)
+		case ConditionCancelled:
+			in.Status = fmt.Sprintf("Cancelled at %s", t)
 		}
 	}
 }
@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_UpdateConditions(t *testing.T) {
+	at := metav1.NewTime(time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC))
+	status := &StandSchedulePolicyStatus{}
+
+	status.UpdateConditions([]StatusCondition{
+		{Type: ConditionDegraded, Status: StatusShutdown, LastTransitionTime: at, Message: "azure provider is disabled"},
+		{Type: ConditionProviderDisabled, Status: StatusShutdown, LastTransitionTime: at, Message: "provider message"},
+		{Type: ConditionProviderDisabled, Status: StatusStartup, LastTransitionTime: at, Message: "provider message"},
+	})
+
+	// provider conditions are kept, but don't change schedule status
+	assert.Len(t, status.Conditions, 3)
+	assert.Equal(t, ScheduleStatus{Status: "Degraded at 2022-10-01T12:00:00Z", Message: "azure provider is disabled"}, status.Shutdown)
+	assert.Equal(t, ScheduleStatus{Status: "Disabled"}, status.Startup)
+}
@@ -0,0 +1,162 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_TimeoutsValidate(t *testing.T) {
+	duration := func(d time.Duration) metav1.Duration {
+		return metav1.Duration{Duration: d}
+	}
+	defaults := TimeoutsSpec{
+		Execution:           duration(time.Minute * 45),
+		Deadline:            duration(time.Minute * 91),
+		WaitStatefulSetPods: duration(time.Minute * 3),
+		WaitDeploymentPods:  duration(time.Minute * 1),
+		WaitTerminatingPods: duration(time.Minute * 1),
+		WaitPodsInterval:    duration(time.Second * 15),
+		WaitWave:            duration(time.Minute * 5),
+	}
+	cases := []struct {
+		name     string
+		timeouts TimeoutsSpec
+		expValid bool
+	}{
+		{
+			name:     "defaults",
+			timeouts: TimeoutsSpec{},
+			expValid: true,
+		},
+		{
+			name:     "longer execution",
+			timeouts: TimeoutsSpec{Execution: duration(time.Minute * 90)},
+			expValid: true,
+		},
+		{
+			name:     "deadline less than execution",
+			timeouts: TimeoutsSpec{Deadline: duration(time.Minute * 30)},
+			expValid: false,
+		},
+		{
+			name:     "wait greater than execution",
+			timeouts: TimeoutsSpec{Execution: duration(time.Minute * 2)},
+			expValid: false,
+		},
+		{
+			name:     "wave wait greater than execution",
+			timeouts: TimeoutsSpec{Execution: duration(time.Minute * 4)},
+			expValid: false,
+		},
+		{
+			name:     "negative wave wait",
+			timeouts: TimeoutsSpec{WaitWave: duration(-time.Minute)},
+			expValid: false,
+		},
+		{
+			name:     "negative wait",
+			timeouts: TimeoutsSpec{WaitDeploymentPods: duration(-time.Minute)},
+			expValid: false,
+		},
+		{
+			name:     "negative deadline",
+			timeouts: TimeoutsSpec{Deadline: duration(-time.Minute)},
+			expValid: false,
+		},
+		{
+			name:     "wait less than interval",
+			timeouts: TimeoutsSpec{WaitPodsInterval: duration(time.Minute * 2)},
+			expValid: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.timeouts.Validate(defaults)
+
+			assert.Equal(t, tc.expValid, err == nil)
+		})
+	}
+}
+
+func Test_ExecutionCheckpointHasStep(t *testing.T) {
+	checkpoint := &ExecutionCheckpoint{
+		Type:           StatusShutdown,
+		CompletedSteps: []string{"namespace/first", "azure/1"},
+	}
+	cases := []struct {
+		name    string
+		step    string
+		expDone bool
+	}{
+		{
+			name:    "completed namespace",
+			step:    "namespace/first",
+			expDone: true,
+		},
+		{
+			name:    "completed azure group",
+			step:    "azure/1",
+			expDone: true,
+		},
+		{
+			name:    "not completed namespace",
+			step:    "namespace/second",
+			expDone: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expDone, checkpoint.HasStep(tc.step))
+		})
+	}
+}
+
+func Test_GetJitter(t *testing.T) {
+	cases := []struct {
+		name   string
+		jitter time.Duration
+	}{
+		{
+			name:   "disabled",
+			jitter: 0,
+		},
+		{
+			name:   "minutes",
+			jitter: time.Minute * 10,
+		},
+		{
+			name:   "seconds",
+			jitter: time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &StandSchedulePolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy"},
+				Spec:       StandSchedulePolicySpec{Jitter: metav1.Duration{Duration: tc.jitter}},
+			}
+
+			jitter := policy.GetJitter()
+
+			assert.Equal(t, jitter, policy.GetJitter())
+			assert.GreaterOrEqual(t, jitter, time.Duration(0))
+			if tc.jitter > 0 {
+				assert.Less(t, jitter, tc.jitter)
+			} else {
+				assert.Zero(t, jitter)
+			}
+		})
+	}
+}
@@ -12,12 +12,19 @@ Authored by The Infrastructure Platform Team.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureResource) DeepCopyInto(out *AzureResource) {
 	*out = *in
+	if in.TagSelector != nil {
+		in, out := &in.TagSelector, &out.TagSelector
+		*out = new(AzureTagSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.LeadTime = in.LeadTime
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureResource.
@@ -35,7 +42,9 @@ func (in AzureResourceList) DeepCopyInto(out *AzureResourceList) {
 	{
 		in := &in
 		*out = make(AzureResourceList, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -49,6 +58,85 @@ func (in AzureResourceList) DeepCopy() AzureResourceList {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureResourceStatus) DeepCopyInto(out *AzureResourceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureResourceStatus.
+func (in *AzureResourceStatus) DeepCopy() *AzureResourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureResourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureTagRequirement) DeepCopyInto(out *AzureTagRequirement) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureTagRequirement.
+func (in *AzureTagRequirement) DeepCopy() *AzureTagRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureTagRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureTagSelector) DeepCopyInto(out *AzureTagSelector) {
+	*out = *in
+	if in.MatchTags != nil {
+		in, out := &in.MatchTags, &out.MatchTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]AzureTagRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureTagSelector.
+func (in *AzureTagSelector) DeepCopy() *AzureTagSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureTagSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerAppScale) DeepCopyInto(out *ContainerAppScale) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerAppScale.
+func (in *ContainerAppScale) DeepCopy() *ContainerAppScale {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerAppScale)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CronSchedule) DeepCopyInto(out *CronSchedule) {
 	*out = *in
@@ -64,13 +152,133 @@ func (in *CronSchedule) DeepCopy() *CronSchedule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EvictionSpec) DeepCopyInto(out *EvictionSpec) {
+	*out = *in
+	out.FallbackTimeout = in.FallbackTimeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EvictionSpec.
+func (in *EvictionSpec) DeepCopy() *EvictionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EvictionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecutionCheckpoint) DeepCopyInto(out *ExecutionCheckpoint) {
+	*out = *in
+	in.FireTime.DeepCopyInto(&out.FireTime)
+	if in.CompletedSteps != nil {
+		in, out := &in.CompletedSteps, &out.CompletedSteps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionCheckpoint.
+func (in *ExecutionCheckpoint) DeepCopy() *ExecutionCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecutionCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FinalizerSpec) DeepCopyInto(out *FinalizerSpec) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FinalizerSpec.
+func (in *FinalizerSpec) DeepCopy() *FinalizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FinalizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolScale) DeepCopyInto(out *NodePoolScale) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolScale.
+func (in *NodePoolScale) DeepCopy() *NodePoolScale {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolScale)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSpec) DeepCopyInto(out *QuotaSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Hard != nil {
+		in, out := &in.Hard, &out.Hard
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSpec.
+func (in *QuotaSpec) DeepCopy() *QuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessSpec) DeepCopyInto(out *ReadinessSpec) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessSpec.
+func (in *ReadinessSpec) DeepCopy() *ReadinessSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourcesSpec) DeepCopyInto(out *ResourcesSpec) {
 	*out = *in
 	if in.Azure != nil {
 		in, out := &in.Azure, &out.Azure
 		*out = make(AzureResourceList, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AzureCredentials != nil {
+		in, out := &in.AzureCredentials, &out.AzureCredentials
+		*out = new(corev1.SecretReference)
+		**out = **in
 	}
 }
 
@@ -180,6 +388,17 @@ func (in *StandSchedulePolicySpec) DeepCopyInto(out *StandSchedulePolicySpec) {
 	*out = *in
 	out.Schedules = in.Schedules
 	in.Resources.DeepCopyInto(&out.Resources)
+	out.Eviction = in.Eviction
+	out.Readiness = in.Readiness
+	if in.StartupOrder != nil {
+		in, out := &in.StartupOrder, &out.StartupOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Jitter = in.Jitter
+	in.Quota.DeepCopyInto(&out.Quota)
+	out.Finalizer = in.Finalizer
+	out.Timeouts = in.Timeouts
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StandSchedulePolicySpec.
@@ -204,6 +423,32 @@ func (in *StandSchedulePolicyStatus) DeepCopyInto(out *StandSchedulePolicyStatus
 	}
 	out.Startup = in.Startup
 	out.Shutdown = in.Shutdown
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(ExecutionCheckpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodePools != nil {
+		in, out := &in.NodePools, &out.NodePools
+		*out = make(map[string]NodePoolScale, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ContainerApps != nil {
+		in, out := &in.ContainerApps, &out.ContainerApps
+		*out = make(map[string]ContainerAppScale, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AzureResources != nil {
+		in, out := &in.AzureResources, &out.AzureResources
+		*out = make(map[string]AzureResourceStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StandSchedulePolicyStatus.
@@ -231,3 +476,25 @@ func (in *StatusCondition) DeepCopy() *StatusCondition {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeoutsSpec) DeepCopyInto(out *TimeoutsSpec) {
+	*out = *in
+	out.Execution = in.Execution
+	out.Deadline = in.Deadline
+	out.WaitStatefulSetPods = in.WaitStatefulSetPods
+	out.WaitDeploymentPods = in.WaitDeploymentPods
+	out.WaitTerminatingPods = in.WaitTerminatingPods
+	out.WaitPodsInterval = in.WaitPodsInterval
+	out.WaitWave = in.WaitWave
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeoutsSpec.
+func (in *TimeoutsSpec) DeepCopy() *TimeoutsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeoutsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
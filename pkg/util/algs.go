@@ -2,6 +2,7 @@ package util
 
 import (
 	"sync"
+	"time"
 
 	"go.uber.org/multierr"
 )
@@ -13,6 +14,13 @@ func Reverse[T any](source []T) (ret []T) {
 	return ret
 }
 
+func Min[T int | int32 | int64 | time.Duration](a, b T) T {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 func MapKeys[K comparable, V any](m map[K]V) []K {
 	r := make([]K, 0, len(m))
 	for k := range m {
@@ -65,3 +73,30 @@ func ForEachParallelE[T any](source []T, f func(i int, t T) error) error {
 
 	return multierr.Combine(errors...)
 }
+
+func ForEachBoundedParallelE[T any](source []T, parallelism int, f func(i int, t T) error) error {
+	if parallelism < 1 || parallelism >= len(source) {
+		return ForEachParallelE(source, f)
+	}
+
+	errors := make([]error, len(source))
+	semaphore := make(chan struct{}, parallelism)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(source))
+
+	for i, resource := range source {
+		i := i
+		resource := resource
+
+		semaphore <- struct{}{}
+		go func() {
+			errors[i] = f(i, resource)
+			<-semaphore
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+
+	return multierr.Combine(errors...)
+}
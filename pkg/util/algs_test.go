@@ -0,0 +1,70 @@
+package util
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/multierr"
+)
+
+func Test_ForEachBoundedParallelE(t *testing.T) {
+	cases := []struct {
+		name           string
+		count          int
+		parallelism    int
+		failed         []int
+		expParallelism int32
+	}{
+		{name: "bounded", count: 6, parallelism: 2, expParallelism: 2},
+		{name: "sequential", count: 6, parallelism: 1, expParallelism: 1},
+		{name: "bound over items is not applied", count: 3, parallelism: 10, expParallelism: 3},
+		{name: "no bound", count: 3, parallelism: 0, expParallelism: 3},
+		{name: "errors of all items combined", count: 6, parallelism: 2, failed: []int{1, 4}, expParallelism: 2},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			source := make([]int, tc.count)
+			for i := range source {
+				source[i] = i
+			}
+
+			var (
+				running   int32
+				maxActive int32
+				lock      sync.Mutex
+				processed []int
+			)
+			err := ForEachBoundedParallelE(source, tc.parallelism, func(i int, item int) error {
+				active := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+
+				lock.Lock()
+				if active > maxActive {
+					maxActive = active
+				}
+				processed = append(processed, item)
+				lock.Unlock()
+
+				time.Sleep(time.Millisecond * 20)
+
+				for _, f := range tc.failed {
+					if f == i {
+						return fmt.Errorf("item %d failed", item)
+					}
+				}
+				return nil
+			})
+
+			assert.ElementsMatch(t, source, processed)
+			assert.LessOrEqual(t, maxActive, tc.expParallelism)
+			assert.Len(t, multierr.Errors(err), len(tc.failed))
+		})
+	}
+}
@@ -2,11 +2,71 @@ package util
 
 import (
 	"errors"
+
+	"go.uber.org/multierr"
 )
 
+type (
+	// DegradedError means that operation completed, but target state is not fully reached.
+	DegradedError struct {
+		err error
+	}
+
+	// CancelledError means that operation interrupted by conflicting event.
+	CancelledError struct {
+		reason string
+	}
+)
+
+func NewDegradedError(err error) error {
+	return &DegradedError{err: err}
+}
+
+func (e *DegradedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *DegradedError) Unwrap() error {
+	return e.err
+}
+
+// IsDegraded reports whether all errors combined in err are degraded errors.
+func IsDegraded(err error) bool {
+	errs := multierr.Errors(err)
+	if len(errs) == 0 {
+		return false
+	}
+
+	for _, e := range errs {
+		var degraded *DegradedError
+		if !errors.As(e, &degraded) {
+			return false
+		}
+	}
+	return true
+}
+
+func NewCancelledError(reason string) error {
+	return &CancelledError{reason: reason}
+}
+
+func (e *CancelledError) Error() string {
+	return "cancelled: " + e.reason
+}
+
+// IsCancelled reports whether err is (or wraps) cancelled error.
+func IsCancelled(err error) bool {
+	var cancelled *CancelledError
+	return errors.As(err, &cancelled)
+}
+
 func IgnoreMatchedError(err error, match error) error {
-	if errors.Is(err, match) {
+	if IsMatchedError(err, match) {
 		return nil
 	}
 	return err
 }
+
+func IsMatchedError(err error, match error) bool {
+	return errors.Is(err, match)
+}
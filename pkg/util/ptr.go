@@ -3,3 +3,11 @@ package util
 func Pointer[T any](val T) *T {
 	return &val
 }
+
+func Deref[T any](ptr *T) T {
+	var zero T
+	if ptr == nil {
+		return zero
+	}
+	return *ptr
+}
@@ -7,9 +7,11 @@ import (
 	"testing"
 	"time"
 
+	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/controller"
 	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
 )
@@ -107,6 +109,80 @@ func (f *fixture) AssertDeploymentScaled(namespace string) {
 	}
 }
 
+func (f *fixture) AssertDaemonSetsDisabled(namespace string) {
+	list, err := f.kube.CoreClient().
+		AppsV1().
+		DaemonSets(namespace).
+		List(context.Background(), meta.ListOptions{})
+	if err != nil {
+		f.t.Errorf("Failed to list daemonsets in namespace %s", namespace)
+	}
+
+	for _, ds := range list.Items {
+		_, exists := ds.Annotations[apis.AnnotationPrefix+"/restore-node-selector"]
+		if !exists {
+			f.t.Errorf("Restore annotation not exists in namespace %s for daemonset %s", namespace, ds.Name)
+		}
+
+		if _, disabled := ds.Spec.Template.Spec.NodeSelector[apis.AnnotationPrefix+"/disabled"]; !disabled {
+			f.t.Errorf("DaemonSet %s node selector not disabled in namespace %s", ds.Name, namespace)
+		}
+	}
+}
+
+func (f *fixture) AssertCronJobsSuspended(namespace string, suspended bool) {
+	list, err := f.kube.CoreClient().
+		BatchV1().
+		CronJobs(namespace).
+		List(context.Background(), meta.ListOptions{})
+	if err != nil {
+		f.t.Errorf("Failed to list cronjobs in namespace %s", namespace)
+	}
+
+	for _, cj := range list.Items {
+		if actual := cj.Spec.Suspend != nil && *cj.Spec.Suspend; actual != suspended {
+			f.t.Errorf("CronJob %s in namespace %s suspended %t, expected %t", cj.Name, namespace, actual, suspended)
+		}
+	}
+}
+
+func (f *fixture) AssertAutoscalerMaxReplicas(namespace, name string, maxReplicas int32) {
+	hpa, err := f.kube.CoreClient().
+		AutoscalingV1().
+		HorizontalPodAutoscalers(namespace).
+		Get(context.Background(), name, meta.GetOptions{})
+	if err != nil {
+		f.t.Errorf("Failed to get autoscaler %s in namespace %s", name, namespace)
+		return
+	}
+
+	if hpa.Spec.MaxReplicas != maxReplicas {
+		f.t.Errorf("Autoscaler %s in namespace %s has %d max replicas, expected %d",
+			name, namespace, hpa.Spec.MaxReplicas, maxReplicas)
+	}
+}
+
+func (f *fixture) AssertDaemonSetsEnabled(namespace string) {
+	list, err := f.kube.CoreClient().
+		AppsV1().
+		DaemonSets(namespace).
+		List(context.Background(), meta.ListOptions{})
+	if err != nil {
+		f.t.Errorf("Failed to list daemonsets in namespace %s", namespace)
+	}
+
+	for _, ds := range list.Items {
+		_, exists := ds.Annotations[apis.AnnotationPrefix+"/restore-node-selector"]
+		if exists {
+			f.t.Errorf("Restore annotation exists in namespace %s for daemonset %s", namespace, ds.Name)
+		}
+
+		if ds.Spec.Template.Spec.NodeSelector["kubernetes.io/os"] != "linux" {
+			f.t.Errorf("DaemonSet %s node selector not restored in namespace %s", ds.Name, namespace)
+		}
+	}
+}
+
 func Test_StartController(t *testing.T) {
 	f := NewFixture(t)
 
@@ -148,7 +224,7 @@ func Test_PolicyWithShutdown(t *testing.T) {
 func Test_PolicyWithStartup(t *testing.T) {
 	f := NewFixture(t).
 		WithNamespaces("namespace2").
-		WithZeroQuota("namespace2").
+		WithZeroQuota("namespace2", "test-policy2").
 		WithDeployments(disabledDeploymentObject("namespace2", "test-deployment-1")).
 		WithPolicies(
 			&apis.StandSchedulePolicy{
@@ -211,7 +287,7 @@ func Test_PolicyWithShutdownOverride(t *testing.T) {
 func Test_PolicyWithStartupOverride(t *testing.T) {
 	f := NewFixture(t).
 		WithNamespaces("namespace4").
-		WithZeroQuota("namespace4").
+		WithZeroQuota("namespace4", "test-policy4").
 		WithDeployments(disabledDeploymentObject("namespace4", "test-deployment-1")).
 		WithPolicies(
 			&apis.StandSchedulePolicy{
@@ -388,3 +464,868 @@ func Test_PolicyWithExternalResources(t *testing.T) {
 	f.WaitUntilPolicyStatus("test-policy7", apis.ConditionCompleted, apis.StatusShutdown)
 	f.AssertNamespaceEmptyOrPodsTerminated("namespace7")
 }
+
+func Test_PolicyWithDaemonSets(t *testing.T) {
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace8").
+		WithDaemonSets(daemonSetObject("namespace8", "test-daemonset-1")).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy8",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace8",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy8", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy8", apis.ConditionCompleted, apis.StatusShutdown)
+	f.AssertDaemonSetsDisabled("namespace8")
+
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy8", apis.ConditionCompleted, apis.StatusStartup)
+	f.AssertDaemonSetsEnabled("namespace8")
+}
+
+func Test_PolicyWithCronJobsAndAutoscalers(t *testing.T) {
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace25").
+		WithDeployments(deploymentObject("namespace25", "test-deployment-1")).
+		WithAutoscalers(autoscalerObject("namespace25", "test-deployment-1", 5)).
+		WithCronJobs(cronJobObject("namespace25", "test-cronjob-1")).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy25",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace25",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy25", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy25", apis.ConditionCompleted, apis.StatusShutdown)
+	f.AssertCronJobsSuspended("namespace25", true)
+
+	// bounds changed while stand is stopped are restored from snapshot
+	f.UpdateAutoscalerMaxReplicas("namespace25", "test-deployment-1", 1)
+
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy25", apis.ConditionCompleted, apis.StatusStartup)
+	f.AssertCronJobsSuspended("namespace25", false)
+	f.AssertAutoscalerMaxReplicas("namespace25", "test-deployment-1", 5)
+}
+
+func Test_PolicyWithEnforceShutdown(t *testing.T) {
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace9").
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy9",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace9",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources:       apis.ResourcesSpec{},
+					EnforceShutdown: true,
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy9", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy9", apis.ConditionCompleted, apis.StatusShutdown)
+
+	// deployed while stand is stopped
+	f.WithDeployments(deploymentObject("namespace9", "test-deployment-1"))
+	f.WaitUntilDeploymentReplicas("namespace9", "test-deployment-1", 0)
+
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy9", apis.ConditionCompleted, apis.StatusStartup)
+	f.WaitUntilDeploymentReplicas("namespace9", "test-deployment-1", 3)
+	f.AssertDeploymentScaled("namespace9")
+}
+
+func Test_PolicyWithFinalizer(t *testing.T) {
+	f := NewFixture(t).
+		WithNamespaces("namespace10").
+		WithDeployments(disabledDeploymentObject("namespace10", "test-deployment-1")).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy10",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace10",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Hour * 24).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Hour * 24).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{},
+					Finalizer: apis.FinalizerSpec{
+						Enabled: true,
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyFinalizer("test-policy10")
+	f.DeletePolicyAndWait("test-policy10")
+	f.AssertDeploymentScaled("namespace10")
+}
+
+func Test_PolicyWithFlexibleServers(t *testing.T) {
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute*10)).
+		WithNamespaces("namespace11").
+		WithDeployments(deploymentObject("namespace11", "test-deployment-1")).
+		WithAzureResources(
+			azureMySQLFlexible("test-1-rg", "test-mysql-flexible-1"),
+			azurePostgreSQLFlexible("test-1-rg", "test-postgresql-flexible-1"),
+			azurePostgreSQLFlexible("test-1-rg", "test-postgresql-flexible-2"),
+		).
+		WithStartupFailed(azurePostgreSQLFlexible("test-1-rg", "test-postgresql-flexible-2")).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy11",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace11",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceMySQLFlexible,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-mysql-flexible",
+								Priority:           0,
+							},
+							{
+								Type:               apis.AzureResourcePostgreSQLFlexible,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-postgresql-flexible",
+								Priority:           1,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy11", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy11", apis.ConditionCompleted, apis.StatusShutdown)
+
+	// failed startup of postgresql flexible server is reported
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy11", apis.ConditionFailed, apis.StatusStartup)
+}
+
+func Test_PolicyWithNodePools(t *testing.T) {
+	pool := azureNodePool("test-1-rg", "test-aks", "agents")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute*10)).
+		WithNamespaces("namespace12").
+		WithDeployments(deploymentObject("namespace12", "test-deployment-1")).
+		WithAzureResources(
+			azureVMScaleSet("test-1-rg", "test-vmss-1"),
+			pool,
+		).
+		WithNodePoolScale(pool, apis.NodePoolScale{Count: 2, EnableAutoScaling: true, MinCount: 1, MaxCount: 5}).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy12",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace12",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceAKSNodePool,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "agents",
+								Priority:           0,
+							},
+							{
+								Type:               apis.AzureResourceVMScaleSet,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vmss",
+								Priority:           1,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy12", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy12", apis.ConditionCompleted, apis.StatusShutdown)
+	f.WaitUntilNodePoolScaleStored("test-policy12", pool)
+
+	// stored scale is restored on startup
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy12", apis.ConditionCompleted, apis.StatusStartup)
+	f.AssertNodePoolScaleRestored(pool)
+}
+
+func Test_PolicyWithNodePoolShutdownFailed(t *testing.T) {
+	pool := azureNodePool("test-1-rg", "test-aks", "agents")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute*10)).
+		WithNamespaces("namespace19").
+		WithAzureResources(pool).
+		WithNodePoolScale(pool, apis.NodePoolScale{Count: 3}).
+		WithShutdownFailed(pool).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy19",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace19",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceAKSNodePool,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "agents",
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// scale is stored before shutdown, so failed shutdown doesn't lose it
+	f.WaitUntilPolicyStatus("test-policy19", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy19", apis.ConditionFailed, apis.StatusShutdown)
+	f.WaitUntilNodePoolScaleStored("test-policy19", pool)
+
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy19", apis.ConditionCompleted, apis.StatusStartup)
+	f.AssertNodePoolScaleRestored(pool)
+}
+
+func Test_PolicyWithWebAndContainerApps(t *testing.T) {
+	webApp := azureAppService("test-1-rg", "test-web")
+	functionApp := azureFunctionApp("test-1-rg", "test-func")
+	containerApp := azureContainerApp("test-1-rg", "test-app")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute*10)).
+		WithNamespaces("namespace13").
+		WithDeployments(deploymentObject("namespace13", "test-deployment-1")).
+		WithAzureResources(webApp, functionApp, containerApp).
+		WithContainerAppReplicas(containerApp, apis.ContainerAppScale{MinReplicas: 2}).
+		WithShutdownFailed(functionApp).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy13",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace13",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceContainerApp,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-app",
+								Priority:           0,
+							},
+							{
+								// function app is not matched by app service type, so its failure is not hit
+								Type:               apis.AzureResourceAppService,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-.*",
+								Priority:           1,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy13", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy13", apis.ConditionCompleted, apis.StatusShutdown)
+	f.WaitUntilContainerAppReplicasStored("test-policy13", containerApp)
+
+	// stored replicas are restored on startup
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy13", apis.ConditionCompleted, apis.StatusStartup)
+	f.AssertContainerAppReplicasRestored(containerApp)
+}
+
+func Test_PolicyWithAzureCredentialsAndSubscription(t *testing.T) {
+	vm := azureVMInSubscription("66666666-7777-8888-9999-000000000000", "test-1-rg", "test-vm-1")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace14").
+		WithCredentialsNamespace("namespace14").
+		WithSecrets(secretObject("namespace14", "azure-credentials", map[string]string{
+			"tenantId":     "tenant",
+			"clientId":     "team-client",
+			"clientSecret": "secret",
+		})).
+		WithAzureResources(vm).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy14",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace14",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Cron: "@yearly",
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								SubscriptionId:     "66666666-7777-8888-9999-000000000000",
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								Priority:           0,
+							},
+						},
+						AzureCredentials: &core.SecretReference{
+							Name:      "azure-credentials",
+							Namespace: "namespace14",
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy14", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy14", apis.ConditionCompleted, apis.StatusShutdown)
+	f.AssertAzureClientRequested("66666666-7777-8888-9999-000000000000", "team-client")
+}
+
+func Test_PolicyWithAzureCredentialsNotAllowed(t *testing.T) {
+	vm := azureVMInSubscription("66666666-7777-8888-9999-000000000000", "test-1-rg", "test-vm-1")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute*10)).
+		WithNamespaces("namespace20", "namespace21").
+		WithCredentialsNamespace("namespace21").
+		WithSecrets(secretObject("namespace20", "azure-credentials", map[string]string{
+			"tenantId":     "tenant",
+			"clientId":     "team-client",
+			"clientSecret": "secret",
+		})).
+		WithAzureResources(vm).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy20",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace20",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Cron: "@yearly",
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								SubscriptionId:     "66666666-7777-8888-9999-000000000000",
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								Priority:           0,
+							},
+						},
+						AzureCredentials: &core.SecretReference{
+							Name:      "azure-credentials",
+							Namespace: "namespace20",
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// secret outside of credentials namespace is not read, so resources are not touched
+	f.WaitUntilPolicyStatus("test-policy20", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy20", apis.ConditionFailed, apis.StatusShutdown)
+	f.AssertAzureOperations(vm, 0)
+}
+
+func Test_PolicyWithDisabledAzure(t *testing.T) {
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithoutAzure().
+		WithNamespaces("namespace15").
+		WithDeployments(deploymentObject("namespace15", "test-deployment-1")).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy15",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace15",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Cron: "@yearly",
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								Priority:           0,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// kubernetes resources are stopped, while azure ones are skipped
+	f.WaitUntilPolicyStatus("test-policy15", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy15", apis.ConditionDegraded, apis.StatusShutdown)
+	f.WaitUntilDeploymentReplicas("namespace15", "test-deployment-1", 0)
+	f.WaitUntilPolicyStatus("test-policy15", apis.ConditionProviderDisabled, apis.StatusShutdown)
+}
+
+func Test_PolicyWithAzureResourcesInTargetState(t *testing.T) {
+	stopped := azureMySQL("test-1-rg", "test-mysql-1")
+	running := azureVM("test-1-rg", "test-vm-1")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute*10)).
+		WithNamespaces("namespace16").
+		WithAzureResources(stopped, running).
+		WithPowerState(stopped, azure.PowerStateStopped).
+		WithPowerState(running, azure.PowerStateRunning).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy16",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace16",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceManagedMySQL,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-mysql",
+								Priority:           0,
+							},
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								Priority:           1,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// already stopped server is skipped on shutdown
+	f.WaitUntilPolicyStatus("test-policy16", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy16", apis.ConditionCompleted, apis.StatusShutdown)
+	f.WaitUntilAzureResourcePowerState("test-policy16", stopped, azure.PowerStateStopped)
+	f.WaitUntilAzureResourcePowerState("test-policy16", running, azure.PowerStateStopped)
+	f.AssertAzureOperations(stopped, 0)
+	f.AssertAzureOperations(running, 1)
+
+	// both resources are started, as they were stopped by now
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy16", apis.ConditionCompleted, apis.StatusStartup)
+	f.WaitUntilAzureResourcePowerState("test-policy16", stopped, azure.PowerStateRunning)
+	f.WaitUntilAzureResourcePowerState("test-policy16", running, azure.PowerStateRunning)
+	f.AssertAzureOperations(stopped, 1)
+	f.AssertAzureOperations(running, 2)
+}
+
+func Test_PolicyWithAwaitedAzureShutdown(t *testing.T) {
+	mysql := azureMySQL("test-1-rg", "test-mysql-1")
+	vm := azureVM("test-1-rg", "test-vm-1")
+	vmss := azureVMScaleSet("test-1-rg", "test-vmss-1")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute*10)).
+		WithNamespaces("namespace17").
+		WithAzureResources(mysql, vm, vmss).
+		WithOperationHanging(vm).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy17",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace17",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Cron: "@yearly",
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								Priority:           0,
+								Wait:               true,
+								WaitTimeout:        meta.Duration{Duration: time.Second},
+							},
+							{
+								Type:               apis.AzureResourceVMScaleSet,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vmss",
+								Priority:           0,
+							},
+							{
+								Type:               apis.AzureResourceManagedMySQL,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-mysql",
+								Priority:           1,
+								Wait:               true,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// timed out shutdown degrades execution, but next priority is shut down anyway
+	f.WaitUntilPolicyStatus("test-policy17", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy17", apis.ConditionDegraded, apis.StatusShutdown)
+	f.WaitUntilAzureResourceOutcome("test-policy17", vm, apis.AzureOutcomeTimedOut)
+	f.WaitUntilAzureResourceOutcome("test-policy17", vmss, apis.AzureOutcomeRequested)
+	f.WaitUntilAzureResourceOutcome("test-policy17", mysql, apis.AzureOutcomeCompleted)
+}
+
+func Test_PolicyWithSupersededShutdown(t *testing.T) {
+	vm := azureVM("test-1-rg", "test-vm-1")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace22").
+		WithAzureResources(vm).
+		WithOperationHanging(vm).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy22",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace22",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								Priority:           0,
+								Wait:               true,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// startup supersedes hanging shutdown, though single executor thread is occupied by it
+	f.WaitUntilPolicyStatus("test-policy22", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.IncreaseTime(time.Minute * 3)
+	f.WaitUntilPolicyStatus("test-policy22", apis.ConditionCancelled, apis.StatusShutdown)
+	f.WaitUntilPolicyStatus("test-policy22", apis.ConditionCompleted, apis.StatusStartup)
+}
+
+func Test_PolicyWithSuspend(t *testing.T) {
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace23").
+		WithDeployments(deploymentObject("namespace23", "test-deployment-1")).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy23",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace23",
+					Suspend:               true,
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Cron: "@yearly",
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// shutdown of suspended policy is skipped
+	f.WaitUntilPolicyStatus("test-policy23", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy23", apis.ConditionCancelled, apis.StatusShutdown)
+	f.WaitUntilDeploymentReplicas("namespace23", "test-deployment-1", 3)
+}
+
+func Test_PolicyWithAzureCircuitOpen(t *testing.T) {
+	vm := azureVM("test-1-rg", "test-vm-1")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace18").
+		WithDeployments(deploymentObject("namespace18", "test-deployment-1")).
+		WithAzureResources(vm).
+		WithCircuitOpen(vm).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy18",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace18",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Cron: "@yearly",
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								Priority:           0,
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	// failing azure degrades execution, while kubernetes resources are stopped
+	f.WaitUntilPolicyStatus("test-policy18", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy18", apis.ConditionDegraded, apis.StatusShutdown)
+	f.WaitUntilAzureResourceOutcome("test-policy18", vm, apis.AzureOutcomeFailed)
+	f.WaitUntilDeploymentReplicas("namespace18", "test-deployment-1", 0)
+}
+
+func Test_PolicyWithLeadStartupFailed(t *testing.T) {
+	vm := azureVM("test-1-rg", "test-vm-1")
+
+	f := NewFixture(t).
+		WithClockTime(_Time.Round(time.Minute * 10)).
+		WithNamespaces("namespace24").
+		WithAzureResources(vm).
+		WithStartupFailed(vm).
+		WithPolicies(
+			&apis.StandSchedulePolicy{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "test-policy24",
+				},
+				Spec: apis.StandSchedulePolicySpec{
+					TargetNamespaceFilter: "namespace24",
+					Schedules: apis.SchedulesSpec{
+						Startup: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 5).Format(time.RFC3339),
+						},
+						Shutdown: apis.CronSchedule{
+							Override: _Time.Add(time.Minute * 2).Format(time.RFC3339),
+						},
+					},
+					Resources: apis.ResourcesSpec{
+						Azure: apis.AzureResourceList{
+							{
+								Type:               apis.AzureResourceVirtualMachine,
+								ResourceGroupName:  "test-1-rg",
+								ResourceNameFilter: "test-vm",
+								LeadTime:           meta.Duration{Duration: time.Minute * 2},
+							},
+						},
+					},
+				},
+			},
+		)
+
+	c := f.CreateController()
+	f.AssertControllerStarted(c)
+
+	f.WaitUntilPolicyStatus("test-policy24", apis.ConditionScheduled, apis.StatusShutdown)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy24", apis.ConditionCompleted, apis.StatusShutdown)
+
+	// failed lead startup is attempted again by main startup, which reports failure
+	f.IncreaseTime(time.Minute)
+	f.WaitUntilAzureResourceOutcome("test-policy24", vm, apis.AzureOutcomeFailed)
+	f.IncreaseTime(time.Minute * 2)
+	f.WaitUntilPolicyStatus("test-policy24", apis.ConditionFailed, apis.StatusStartup)
+}
@@ -5,15 +5,29 @@ package test
 import (
 	"context"
 	"fmt"
+	"sync"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
 )
 
 type (
 	azureFixture struct {
+		lock           sync.Mutex
 		resources      []*azure.Resource
 		startupErrors  map[string]error
 		shutdownErrors map[string]error
+		scales         map[string]apis.NodePoolScale
+		restored       map[string]apis.NodePoolScale
+		replicas       map[string]apis.ContainerAppScale
+		restoredApps   map[string]apis.ContainerAppScale
+		clients        map[string]*azure.Credentials
+		states         map[string]azure.PowerState
+		operations     map[string]int
+		hanging        map[string]bool
 	}
 )
 
@@ -24,21 +38,188 @@ func (f *fixture) WithAzureResources(resources ...*azure.Resource) *fixture {
 
 func (f *fixture) WithStartupFailed(resource *azure.Resource) *fixture {
 	f.azure.startupErrors[resource.String()] =
-		fmt.Errorf("shutdown failure for %s", resource)
+		fmt.Errorf("startup failure for %s", resource)
 	return f
 }
 
 func (f *fixture) WithShutdownFailed(resource *azure.Resource) *fixture {
 	f.azure.shutdownErrors[resource.String()] =
-		fmt.Errorf("startup failure for %s", resource)
+		fmt.Errorf("shutdown failure for %s", resource)
+	return f
+}
+
+func (f *fixture) WithCircuitOpen(resource *azure.Resource) *fixture {
+	f.azure.shutdownErrors[resource.String()] =
+		fmt.Errorf("%w: shutdown failures for %s", azure.ErrCircuitOpen, resource)
+	return f
+}
+
+func (f *fixture) WithNodePoolScale(resource *azure.Resource, scale apis.NodePoolScale) *fixture {
+	f.azure.scales[resource.String()] = scale
+	return f
+}
+
+func (f *fixture) WaitUntilNodePoolScaleStored(name string, resource *azure.Resource) {
+	err := wait.PollImmediate(_WaitPolicyStatusInterval, _WaitPolicyStatusTimeout, func() (bool, error) {
+		f.t.Logf("Waiting policy (%s) status stores scale of %s", name, resource)
+		policy, err := f.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			Get(context.Background(), name, meta.GetOptions{})
+
+		if err != nil {
+			return false, err
+		}
+
+		_, ok := policy.Status.NodePools[resource.GetID()]
+		return ok, nil
+	})
+
+	if err != nil {
+		f.t.Error(err)
+	}
+}
+
+func (f *fixture) AssertNodePoolScaleRestored(resource *azure.Resource) {
+	f.azure.lock.Lock()
+	defer f.azure.lock.Unlock()
+
+	expected, ok := f.azure.scales[resource.String()]
+	if !ok {
+		f.t.Fatalf("no scale configured for %s", resource)
+	}
+	if actual := f.azure.restored[resource.String()]; actual != expected {
+		f.t.Errorf("node pool %s restored with %+v, expected %+v", resource, actual, expected)
+	}
+}
+
+func (f *fixture) WithContainerAppReplicas(resource *azure.Resource, replicas apis.ContainerAppScale) *fixture {
+	f.azure.replicas[resource.String()] = replicas
+	return f
+}
+
+func (f *fixture) WaitUntilContainerAppReplicasStored(name string, resource *azure.Resource) {
+	err := wait.PollImmediate(_WaitPolicyStatusInterval, _WaitPolicyStatusTimeout, func() (bool, error) {
+		f.t.Logf("Waiting policy (%s) status stores replicas of %s", name, resource)
+		policy, err := f.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			Get(context.Background(), name, meta.GetOptions{})
+
+		if err != nil {
+			return false, err
+		}
+
+		_, ok := policy.Status.ContainerApps[resource.GetID()]
+		return ok, nil
+	})
+
+	if err != nil {
+		f.t.Error(err)
+	}
+}
+
+func (f *fixture) AssertContainerAppReplicasRestored(resource *azure.Resource) {
+	f.azure.lock.Lock()
+	defer f.azure.lock.Unlock()
+
+	expected, ok := f.azure.replicas[resource.String()]
+	if !ok {
+		f.t.Fatalf("no replicas configured for %s", resource)
+	}
+	if actual := f.azure.restoredApps[resource.String()]; actual != expected {
+		f.t.Errorf("container app %s restored with %+v, expected %+v", resource, actual, expected)
+	}
+}
+
+func (f *fixture) WithPowerState(resource *azure.Resource, state azure.PowerState) *fixture {
+	f.azure.states[resource.String()] = state
+	return f
+}
+
+func (f *fixture) WaitUntilAzureResourcePowerState(name string, resource *azure.Resource, state azure.PowerState) {
+	err := wait.PollImmediate(_WaitPolicyStatusInterval, _WaitPolicyStatusTimeout, func() (bool, error) {
+		f.t.Logf("Waiting policy (%s) status stores power state %s of %s", name, state, resource)
+		policy, err := f.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			Get(context.Background(), name, meta.GetOptions{})
+
+		if err != nil {
+			return false, err
+		}
+
+		return policy.Status.AzureResources[resource.GetID()].PowerState == string(state), nil
+	})
+
+	if err != nil {
+		f.t.Error(err)
+	}
+}
+
+func (f *fixture) WithOperationHanging(resource *azure.Resource) *fixture {
+	f.azure.hanging[resource.String()] = true
 	return f
 }
 
+func (f *fixture) WaitUntilAzureResourceOutcome(name string, resource *azure.Resource, outcome apis.AzureResourceOutcome) {
+	err := wait.PollImmediate(_WaitPolicyStatusInterval, _WaitPolicyStatusTimeout, func() (bool, error) {
+		f.t.Logf("Waiting policy (%s) status stores outcome %s of %s", name, outcome, resource)
+		policy, err := f.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			Get(context.Background(), name, meta.GetOptions{})
+
+		if err != nil {
+			return false, err
+		}
+
+		return policy.Status.AzureResources[resource.GetID()].Outcome == outcome, nil
+	})
+
+	if err != nil {
+		f.t.Error(err)
+	}
+}
+
+func (f *fixture) AssertAzureOperations(resource *azure.Resource, expected int) {
+	f.azure.lock.Lock()
+	defer f.azure.lock.Unlock()
+
+	if actual := f.azure.operations[resource.String()]; actual != expected {
+		f.t.Errorf("azure resource %s got %d shutdown or startup operations, expected %d", resource, actual, expected)
+	}
+}
+
+func (f *fixture) AssertAzureClientRequested(subscriptionId, clientId string) {
+	f.azure.lock.Lock()
+	defer f.azure.lock.Unlock()
+
+	cred, ok := f.azure.clients[subscriptionId]
+	if !ok {
+		f.t.Fatalf("no client requested for subscription %s", subscriptionId)
+	}
+	if actual := cred.ClientId; actual != clientId {
+		f.t.Errorf("client for subscription %s requested with client id %q, expected %q", subscriptionId, actual, clientId)
+	}
+}
+
+func (az *azureFixture) Get(subscriptionId string, cred *azure.Credentials) (azure.Interface, error) {
+	az.lock.Lock()
+	defer az.lock.Unlock()
+
+	if cred == nil {
+		cred = &azure.Credentials{}
+	}
+	az.clients[subscriptionId] = cred
+	return az, nil
+}
+
 func (az *azureFixture) List(_ context.Context, resourceType azure.ResourceType, resourceGroup string) ([]*azure.Resource, error) {
 	var ret []*azure.Resource
 
 	for _, resource := range az.resources {
-		if resource.GetType() == resourceType && resource.GetResourceGroup() == resourceGroup {
+		if resource.GetType() == resourceType && (resourceGroup == "" || resource.GetResourceGroup() == resourceGroup) {
 			ret = append(ret, resource)
 		}
 	}
@@ -46,10 +227,76 @@ func (az *azureFixture) List(_ context.Context, resourceType azure.ResourceType,
 	return ret, nil
 }
 
-func (az *azureFixture) Shutdown(_ context.Context, resource *azure.Resource, _ bool) error {
-	return az.shutdownErrors[resource.String()]
+func (az *azureFixture) Capture(_ context.Context, resource *azure.Resource) error {
+	az.lock.Lock()
+	defer az.lock.Unlock()
+
+	if scale, ok := az.scales[resource.String()]; ok {
+		resource.SetScale(&scale)
+	}
+	if replicas, ok := az.replicas[resource.String()]; ok {
+		resource.SetReplicas(&replicas)
+	}
+	return nil
+}
+
+func (az *azureFixture) Shutdown(ctx context.Context, resource *azure.Resource, wait bool) error {
+	if err := az.hang(ctx, resource, wait); err != nil {
+		return err
+	}
+
+	az.lock.Lock()
+	defer az.lock.Unlock()
+
+	return az.operate(resource, az.shutdownErrors, azure.PowerStateStopped)
+}
+
+func (az *azureFixture) Startup(ctx context.Context, resource *azure.Resource, wait bool) error {
+	if err := az.hang(ctx, resource, wait); err != nil {
+		return err
+	}
+
+	az.lock.Lock()
+	defer az.lock.Unlock()
+
+	if scale := resource.GetScale(); scale != nil {
+		az.restored[resource.String()] = *scale
+	}
+	if replicas := resource.GetReplicas(); replicas != nil {
+		az.restoredApps[resource.String()] = *replicas
+	}
+	return az.operate(resource, az.startupErrors, azure.PowerStateRunning)
+}
+
+func (az *azureFixture) GetState(_ context.Context, resource *azure.Resource) (azure.PowerState, error) {
+	az.lock.Lock()
+	defer az.lock.Unlock()
+
+	if state, ok := az.states[resource.String()]; ok {
+		return state, nil
+	}
+	return azure.PowerStateUnknown, nil
+}
+
+// operate records operation on resource and moves it to target state, unless failure configured for it.
+func (az *azureFixture) operate(resource *azure.Resource, failures map[string]error, target azure.PowerState) error {
+	az.operations[resource.String()]++
+	if err := failures[resource.String()]; err != nil {
+		return err
+	}
+	az.states[resource.String()] = target
+	return nil
 }
 
-func (az *azureFixture) Startup(_ context.Context, resource *azure.Resource, _ bool) error {
-	return az.startupErrors[resource.String()]
+// hang blocks awaited operation on hanging resource until context is done.
+func (az *azureFixture) hang(ctx context.Context, resource *azure.Resource, wait bool) error {
+	az.lock.Lock()
+	hanging := az.hanging[resource.String()]
+	az.lock.Unlock()
+
+	if !hanging || !wait {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
 }
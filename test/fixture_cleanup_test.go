@@ -71,7 +71,7 @@ func (f *fixtureCleanup) Handler() {
 			StandSchedulesV1().
 			StandSchedulePolicies().
 			Delete(context.Background(), policy, meta.DeleteOptions{})
-		if err != nil {
+		if err != nil && !errors.IsNotFound(err) {
 			f.t.Fatal(err)
 		}
 	}
@@ -7,7 +7,10 @@ import (
 	"time"
 
 	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -52,6 +55,19 @@ func (f *fixture) WithPods(pods ...*core.Pod) *fixture {
 	return f
 }
 
+func (f *fixture) WithSecrets(secrets ...*core.Secret) *fixture {
+	for _, secret := range secrets {
+		_, err := f.kube.CoreClient().
+			CoreV1().
+			Secrets(secret.Namespace).
+			Create(context.Background(), secret, meta.CreateOptions{})
+		if err != nil {
+			f.t.Error(err)
+		}
+	}
+	return f
+}
+
 func (f *fixture) WithDeployments(deployments ...*apps.Deployment) *fixture {
 	for _, deployment := range deployments {
 		_, err := f.kube.CoreClient().
@@ -65,11 +81,72 @@ func (f *fixture) WithDeployments(deployments ...*apps.Deployment) *fixture {
 	return f
 }
 
-func (f *fixture) WithZeroQuota(namespace string) *fixture {
+func (f *fixture) WithDaemonSets(daemonSets ...*apps.DaemonSet) *fixture {
+	for _, ds := range daemonSets {
+		_, err := f.kube.CoreClient().
+			AppsV1().
+			DaemonSets(ds.Namespace).
+			Create(context.Background(), ds, meta.CreateOptions{})
+		if err != nil {
+			f.t.Error(err)
+		}
+	}
+	return f
+}
+
+func (f *fixture) WithCronJobs(cronJobs ...*batch.CronJob) *fixture {
+	for _, cj := range cronJobs {
+		_, err := f.kube.CoreClient().
+			BatchV1().
+			CronJobs(cj.Namespace).
+			Create(context.Background(), cj, meta.CreateOptions{})
+		if err != nil {
+			f.t.Error(err)
+		}
+	}
+	return f
+}
+
+func (f *fixture) WithAutoscalers(autoscalers ...*autoscaling.HorizontalPodAutoscaler) *fixture {
+	for _, hpa := range autoscalers {
+		_, err := f.kube.CoreClient().
+			AutoscalingV1().
+			HorizontalPodAutoscalers(hpa.Namespace).
+			Create(context.Background(), hpa, meta.CreateOptions{})
+		if err != nil {
+			f.t.Error(err)
+		}
+	}
+	return f
+}
+
+func (f *fixture) UpdateAutoscalerMaxReplicas(namespace, name string, maxReplicas int32) {
+	hpa, err := f.kube.CoreClient().
+		AutoscalingV1().
+		HorizontalPodAutoscalers(namespace).
+		Get(context.Background(), name, meta.GetOptions{})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	hpa.Spec.MaxReplicas = maxReplicas
+	_, err = f.kube.CoreClient().
+		AutoscalingV1().
+		HorizontalPodAutoscalers(namespace).
+		Update(context.Background(), hpa, meta.UpdateOptions{})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *fixture) WithZeroQuota(namespace, policy string) *fixture {
 	quota := &core.ResourceQuota{
 		ObjectMeta: meta.ObjectMeta{
 			Name:      "zero-quota",
 			Namespace: namespace,
+			Labels: map[string]string{
+				apis.AnnotationPrefix + "/policy": policy,
+			},
 		},
 		Spec: core.ResourceQuotaSpec{
 			Hard: core.ResourceList{
@@ -128,3 +205,71 @@ func (f *fixture) WaitUntilPolicyStatus(name string, ct apis.ConditionType, sht
 		f.t.Error(err)
 	}
 }
+
+func (f *fixture) WaitUntilDeploymentReplicas(namespace, name string, replicas int32) {
+	err := wait.PollImmediate(_WaitPolicyStatusInterval, _WaitPolicyStatusTimeout, func() (bool, error) {
+		f.t.Logf("Waiting deployment (%s) in namespace %s scaled to %d", name, namespace, replicas)
+		deployment, err := f.kube.CoreClient().
+			AppsV1().
+			Deployments(namespace).
+			Get(context.Background(), name, meta.GetOptions{})
+
+		if err != nil {
+			return false, err
+		}
+
+		return deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == replicas, nil
+	})
+
+	if err != nil {
+		f.t.Error(err)
+	}
+}
+
+func (f *fixture) WaitUntilPolicyFinalizer(name string) {
+	err := wait.PollImmediate(_WaitPolicyStatusInterval, _WaitPolicyStatusTimeout, func() (bool, error) {
+		f.t.Logf("Waiting policy (%s) finalizer", name)
+		policy, err := f.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			Get(context.Background(), name, meta.GetOptions{})
+
+		if err != nil {
+			return false, err
+		}
+
+		return len(policy.Finalizers) > 0, nil
+	})
+
+	if err != nil {
+		f.t.Error(err)
+	}
+}
+
+func (f *fixture) DeletePolicyAndWait(name string) {
+	err := f.kube.StandSchedulesClient().
+		StandSchedulesV1().
+		StandSchedulePolicies().
+		Delete(context.Background(), name, meta.DeleteOptions{})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	err = wait.PollImmediate(_WaitPolicyStatusInterval, _WaitPolicyStatusTimeout, func() (bool, error) {
+		f.t.Logf("Waiting policy (%s) deleted", name)
+		_, err := f.kube.StandSchedulesClient().
+			StandSchedulesV1().
+			StandSchedulePolicies().
+			Get(context.Background(), name, meta.GetOptions{})
+
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, err
+	})
+
+	if err != nil {
+		f.t.Error(err)
+	}
+}
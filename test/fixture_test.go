@@ -10,8 +10,10 @@ import (
 	"go.uber.org/zap"
 	clock "k8s.io/utils/clock/testing"
 
+	"github.com/dodopizza/stand-schedule-policy-controller/internal/azure"
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/controller"
 	"github.com/dodopizza/stand-schedule-policy-controller/internal/kubernetes"
+	apis "github.com/dodopizza/stand-schedule-policy-controller/pkg/apis/standschedules/v1"
 )
 
 var (
@@ -54,8 +56,19 @@ func NewFixture(t *testing.T) *fixture {
 			ExecutorThreadiness:   1,
 			WorkerQueueRetries:    5,
 		},
-		kube:      k,
-		azure:     &azureFixture{},
+		kube: k,
+		azure: &azureFixture{
+			startupErrors:  map[string]error{},
+			shutdownErrors: map[string]error{},
+			scales:         map[string]apis.NodePoolScale{},
+			restored:       map[string]apis.NodePoolScale{},
+			replicas:       map[string]apis.ContainerAppScale{},
+			restoredApps:   map[string]apis.ContainerAppScale{},
+			clients:        map[string]*azure.Credentials{},
+			states:         map[string]azure.PowerState{},
+			operations:     map[string]int{},
+			hanging:        map[string]bool{},
+		},
 		clock:     clock.NewFakeClock(_Time),
 		interrupt: cleanup.interrupt,
 		t:         t,
@@ -69,6 +82,16 @@ func (f *fixture) WithClockTime(ts time.Time) *fixture {
 	return f
 }
 
+func (f *fixture) WithCredentialsNamespace(namespace string) *fixture {
+	f.cfg.CredentialsNamespace = namespace
+	return f
+}
+
+func (f *fixture) WithoutAzure() *fixture {
+	f.azure = nil
+	return f
+}
+
 func (f *fixture) WithoutCleanup() *fixture {
 	f.cleanup.cleanup = false
 	return f
@@ -80,7 +103,11 @@ func (f *fixture) CreateController() *controller.Controller {
 		f.t.Fatal(err)
 	}
 
-	f.cleanup.controller = controller.NewController(f.cfg, l, f.clock, f.kube, f.azure)
+	var az azure.Factory
+	if f.azure != nil {
+		az = f.azure
+	}
+	f.cleanup.controller = controller.NewController(f.cfg, l, f.clock, f.kube, az)
 
 	return f.cleanup.controller
 }
@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -102,11 +104,144 @@ func disabledDeploymentObject(namespace, name string) *apps.Deployment {
 	}
 }
 
+func daemonSetObject(namespace, name string) *apps.DaemonSet {
+	return &apps.DaemonSet{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: apps.DaemonSetSpec{
+			Selector: &meta.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": name,
+				},
+			},
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{
+					Labels: map[string]string{
+						"app": name,
+					},
+				},
+				Spec: core.PodSpec{
+					Containers: []core.Container{
+						{
+							Name:  "test",
+							Image: "nginx",
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+					AutomountServiceAccountToken:  util.Pointer(false),
+					TerminationGracePeriodSeconds: util.Pointer(int64(1)),
+				},
+			},
+		},
+	}
+}
+
+func cronJobObject(namespace, name string) *batch.CronJob {
+	return &batch.CronJob{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: batch.CronJobSpec{
+			Schedule: "0 0 1 1 *",
+			JobTemplate: batch.JobTemplateSpec{
+				Spec: batch.JobSpec{
+					Template: core.PodTemplateSpec{
+						Spec: core.PodSpec{
+							Containers: []core.Container{
+								{
+									Name:  "test",
+									Image: "nginx",
+								},
+							},
+							RestartPolicy:                 core.RestartPolicyNever,
+							AutomountServiceAccountToken:  util.Pointer(false),
+							TerminationGracePeriodSeconds: util.Pointer(int64(1)),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func autoscalerObject(namespace, name string, maxReplicas int32) *autoscaling.HorizontalPodAutoscaler {
+	return &autoscaling.HorizontalPodAutoscaler{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: autoscaling.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       name,
+			},
+			MinReplicas: util.Pointer(int32(1)),
+			MaxReplicas: maxReplicas,
+		},
+	}
+}
+
+func secretObject(namespace, name string, data map[string]string) *core.Secret {
+	return &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		StringData: data,
+	}
+}
+
 func azureMySQL(rg, name string) *azure.Resource {
 	return azure.NewResource(
 		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.DBforMySQL/servers/%s", rg, name))
 }
 
+func azureMySQLFlexible(rg, name string) *azure.Resource {
+	return azure.NewResource(
+		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.DBforMySQL/flexibleServers/%s", rg, name))
+}
+
+func azurePostgreSQLFlexible(rg, name string) *azure.Resource {
+	return azure.NewResource(
+		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.DBforPostgreSQL/flexibleServers/%s", rg, name))
+}
+
+func azureVMScaleSet(rg, name string) *azure.Resource {
+	return azure.NewResource(
+		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.Compute/virtualMachineScaleSets/%s", rg, name))
+}
+
+func azureNodePool(rg, cluster, name string) *azure.Resource {
+	return azure.NewResource(
+		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s/agentPools/%s", rg, cluster, name))
+}
+
+func azureAppService(rg, name string) *azure.Resource {
+	return azure.NewResourceOfKind(
+		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.Web/sites/%s", rg, name), "app")
+}
+
+func azureFunctionApp(rg, name string) *azure.Resource {
+	return azure.NewResourceOfKind(
+		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.Web/sites/%s", rg, name), "functionapp,linux")
+}
+
+func azureContainerApp(rg, name string) *azure.Resource {
+	return azure.NewResource(
+		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.App/containerApps/%s", rg, name))
+}
+
+func azureVMInSubscription(subscriptionId, rg, name string) *azure.Resource {
+	return azure.NewResource(
+		fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines/%s", subscriptionId, rg, name))
+}
+
 func azureVM(rg, name string) *azure.Resource {
 	return azure.NewResource(
 		fmt.Sprintf("/subscriptions/11111111-2222-3333-4444-555555555555/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines/%s", rg, name))